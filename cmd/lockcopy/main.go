@@ -0,0 +1,14 @@
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"golang_course/pkg/lockcopy"
+)
+
+// go build -o lockcopy ./cmd/lockcopy
+// go vet -vettool=$(pwd)/lockcopy ./lessons/sync_primitives/...
+
+func main() {
+	singlechecker.Main(lockcopy.Analyzer)
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"golang_course/pkg/leakcheck"
 )
 
 // go test -v homework_test.go
@@ -32,6 +34,8 @@ func (wp *WorkerPool) Shutdown() {
 }
 
 func TestWorkerPool(t *testing.T) {
+	leakcheck.Verify(t)
+
 	var counter atomic.Int32
 	task := func() {
 		time.Sleep(time.Millisecond * 500)
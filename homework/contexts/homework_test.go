@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"golang_course/pkg/leakcheck"
 )
 
 type Group struct {
@@ -29,6 +31,8 @@ func (g *Group) Wait() error {
 }
 
 func TestErrGroupWithoutError(t *testing.T) {
+	leakcheck.Verify(t)
+
 	var counter atomic.Int32
 	group, _ := NewErrGroup(context.Background())
 
@@ -46,6 +50,8 @@ func TestErrGroupWithoutError(t *testing.T) {
 }
 
 func TestErrGroupWithError(t *testing.T) {
+	leakcheck.Verify(t)
+
 	var counter atomic.Int32
 	group, ctx := NewErrGroup(context.Background())
 
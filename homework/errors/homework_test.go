@@ -2,6 +2,12 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,18 +15,112 @@ import (
 
 // go test -v homework_test.go
 
+// ErrorFormatFunc builds the message of a MultiError from its errors.
+type ErrorFormatFunc func(errs []error) string
+
+func ListFormat(errs []error) string {
+	var builder strings.Builder
+	if len(errs) == 1 {
+		builder.WriteString("1 error occured:\n")
+	} else {
+		fmt.Fprintf(&builder, "%d errors occured:\n", len(errs))
+	}
+
+	for _, err := range errs {
+		builder.WriteString("\t* ")
+		builder.WriteString(err.Error())
+	}
+
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// MultiError collects errors, it's safe to append to it concurrently.
 type MultiError struct {
-	// need to implement
+	// ErrorFormat is ListFormat if nil.
+	ErrorFormat ErrorFormatFunc
+
+	mutex  sync.Mutex
+	errors []error
 }
 
 func (e *MultiError) Error() string {
-	// need to implement
-	return ""
+	errs := e.Errors()
+	if e.ErrorFormat != nil {
+		return e.ErrorFormat(errs)
+	}
+	return ListFormat(errs)
+}
+
+// Errors returns a copy of the collected errors.
+func (e *MultiError) Errors() []error {
+	if e == nil {
+		return nil
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]error(nil), e.errors...)
+}
+
+func (e *MultiError) Len() int {
+	if e == nil {
+		return 0
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.errors)
+}
+
+// Unwrap lets errors.Is and errors.As look into the collected errors.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors()
 }
 
+// ErrorOrNil returns nil if there are no errors, so the result
+// can be returned as error without being a non-nil interface.
+func (e *MultiError) ErrorOrNil() error {
+	if e.Len() == 0 {
+		return nil
+	}
+	return e
+}
+
+// Append adds errors skipping nil ones, the errors
+// of nested multi-errors are added one by one.
+func (e *MultiError) Append(errs ...error) {
+	flattened := flatten(errs)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.errors = append(e.errors, flattened...)
+}
+
+func flatten(errs []error) []error {
+	var flattened []error
+	for _, err := range errs {
+		if multiErr, ok := err.(*MultiError); ok {
+			flattened = append(flattened, multiErr.Errors()...)
+		} else if err != nil {
+			flattened = append(flattened, err)
+		}
+	}
+	return flattened
+}
+
+// Append adds errs to err if it's a MultiError, otherwise
+// creates a new one containing err followed by errs.
 func Append(err error, errs ...error) *MultiError {
-	// need to implement
-	return nil
+	if multiErr, ok := err.(*MultiError); ok && multiErr != nil {
+		multiErr.Append(errs...)
+		return multiErr
+	}
+
+	multiErr := &MultiError{}
+	multiErr.Append(err)
+	multiErr.Append(errs...)
+	return multiErr
 }
 
 func TestMultiError(t *testing.T) {
@@ -31,3 +131,84 @@ func TestMultiError(t *testing.T) {
 	expectedMessage := "2 errors occured:\n\t* error 1\t* error 2\n"
 	assert.EqualError(t, err, expectedMessage)
 }
+
+func TestMultiErrorSingle(t *testing.T) {
+	err := Append(nil, errors.New("error 1"))
+	assert.EqualError(t, err, "1 error occured:\n\t* error 1\n")
+}
+
+func TestMultiErrorIsAs(t *testing.T) {
+	var err error
+	err = Append(err, io.EOF)
+	err = Append(err, &fs.PathError{Op: "open", Path: "/tmp", Err: os.ErrNotExist})
+	err = fmt.Errorf("internal error: %w", err)
+
+	assert.ErrorIs(t, err, io.EOF)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NotErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	var pathErr *fs.PathError
+	if assert.ErrorAs(t, err, &pathErr) {
+		assert.Equal(t, "/tmp", pathErr.Path)
+	}
+}
+
+func TestMultiErrorFlatten(t *testing.T) {
+	err1, err2, err3 := errors.New("error 1"), errors.New("error 2"), errors.New("error 3")
+
+	inner := Append(err1, err2)
+	outer := Append(nil, inner, nil, Append(nil, err3))
+	assert.Equal(t, []error{err1, err2, err3}, outer.Errors())
+
+	// a wrapped multi-error keeps its context
+	wrapped := fmt.Errorf("context: %w", inner)
+	outer = Append(wrapped, err3)
+	assert.Equal(t, []error{wrapped, err3}, outer.Errors())
+
+	// appending to itself doubles the errors
+	inner.Append(inner)
+	assert.Equal(t, []error{err1, err2, err1, err2}, inner.Errors())
+}
+
+func TestMultiErrorFormat(t *testing.T) {
+	err := Append(errors.New("error 1"), errors.New("error 2"))
+	err.ErrorFormat = func(errs []error) string {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	assert.EqualError(t, err, "error 1; error 2")
+}
+
+func TestMultiErrorOrNil(t *testing.T) {
+	var nilErr *MultiError
+	assert.NoError(t, nilErr.ErrorOrNil())
+	assert.NoError(t, Append(nil).ErrorOrNil())
+	assert.NoError(t, Append(nil, nil, nil).ErrorOrNil())
+
+	err := Append(nil, io.EOF).ErrorOrNil()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestMultiErrorConcurrentAppend(t *testing.T) {
+	const goroutines = 8
+	const appends = 1000
+
+	var err error = &MultiError{}
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for range goroutines {
+		go func() {
+			defer wg.Done()
+			for range appends {
+				Append(err, io.EOF)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, goroutines*appends, err.(*MultiError).Len())
+}
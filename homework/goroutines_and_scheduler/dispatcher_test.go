@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ErrPreempted = errors.New("task preempted")
+
+// Handler executes a task. When a task is preempted its context is
+// cancelled with ErrPreempted as the cause, and if the handler gives
+// up by returning ErrPreempted the task is put back into the queue
+// behind the other tasks of the same priority.
+type Handler func(ctx context.Context, task Task) error
+
+// PreemptionHook decides if a running task should give way
+// to an incoming task when all workers are busy.
+type PreemptionHook func(running Task, incoming Task) bool
+
+type PriorityStats struct {
+	Queued     int
+	Dispatched int
+	TotalWait  time.Duration
+	MaxWait    time.Duration
+}
+
+func (s PriorityStats) AverageWait() time.Duration {
+	if s.Dispatched == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Dispatched)
+}
+
+type DispatcherStats struct {
+	QueueDepth int
+	Running    int
+	Priorities map[int]PriorityStats
+}
+
+type runningTask struct {
+	task      Task
+	cancel    context.CancelCauseFunc
+	preempted bool
+}
+
+// Dispatcher runs tasks from the priority Scheduler in several workers.
+type Dispatcher struct {
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	workers    int
+	handler    Handler
+	preempt    PreemptionHook
+	scheduler  Scheduler
+	enqueuedAt map[int]time.Time
+	running    map[int]*runningTask
+	stats      map[int]PriorityStats
+	now        func() time.Time
+}
+
+func NewDispatcher(workers int, handler Handler) *Dispatcher {
+	if workers <= 0 {
+		panic("workers number must be positive")
+	}
+
+	dispatcher := &Dispatcher{
+		workers:    workers,
+		handler:    handler,
+		scheduler:  NewScheduler(),
+		enqueuedAt: make(map[int]time.Time),
+		running:    make(map[int]*runningTask),
+		stats:      make(map[int]PriorityStats),
+		now:        time.Now,
+	}
+
+	dispatcher.cond = sync.NewCond(&dispatcher.mutex)
+	return dispatcher
+}
+
+func (d *Dispatcher) SetPreemptionHook(hook PreemptionHook) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.preempt = hook
+}
+
+func (d *Dispatcher) Submit(task Task) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.enqueue(task)
+	d.tryPreempt(task)
+}
+
+// Run starts the workers and blocks until ctx is cancelled
+// and all running tasks have returned.
+func (d *Dispatcher) Run(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		d.cond.Broadcast()
+	})
+	defer stop()
+
+	wg := sync.WaitGroup{}
+	wg.Add(d.workers)
+	for worker := 0; worker < d.workers; worker++ {
+		go func() {
+			defer wg.Done()
+			d.work(ctx, worker)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (d *Dispatcher) Stats() DispatcherStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	stats := DispatcherStats{
+		QueueDepth: d.scheduler.tasks.Len(),
+		Running:    len(d.running),
+		Priorities: make(map[int]PriorityStats, len(d.stats)),
+	}
+
+	for priority, priorityStats := range d.stats {
+		priorityStats.Queued = 0
+		stats.Priorities[priority] = priorityStats
+	}
+
+	for _, task := range d.scheduler.tasks.data {
+		priorityStats := stats.Priorities[task.Priority]
+		priorityStats.Queued++
+		stats.Priorities[task.Priority] = priorityStats
+	}
+
+	return stats
+}
+
+func (d *Dispatcher) work(ctx context.Context, worker int) {
+	for {
+		task, taskCtx, ok := d.next(ctx, worker)
+		if !ok {
+			return
+		}
+
+		err := d.handler(taskCtx, task)
+		d.finish(ctx, worker, task, err)
+	}
+}
+
+func (d *Dispatcher) next(ctx context.Context, worker int) (Task, context.Context, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for d.scheduler.tasks.Empty() && ctx.Err() == nil {
+		d.cond.Wait()
+	}
+
+	if ctx.Err() != nil {
+		return Task{}, nil, false
+	}
+
+	task, _ := d.scheduler.GetTask()
+	waited := d.now().Sub(d.enqueuedAt[task.Identifier])
+	delete(d.enqueuedAt, task.Identifier)
+
+	priorityStats := d.stats[task.Priority]
+	priorityStats.Dispatched++
+	priorityStats.TotalWait += waited
+	priorityStats.MaxWait = max(priorityStats.MaxWait, waited)
+	d.stats[task.Priority] = priorityStats
+
+	taskCtx, cancel := context.WithCancelCause(ctx)
+	d.running[worker] = &runningTask{task: task, cancel: cancel}
+	return task, taskCtx, true
+}
+
+func (d *Dispatcher) finish(ctx context.Context, worker int, task Task, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.running[worker].cancel(nil)
+	delete(d.running, worker)
+
+	if errors.Is(err, ErrPreempted) && ctx.Err() == nil {
+		d.enqueue(task)
+	}
+}
+
+func (d *Dispatcher) enqueue(task Task) {
+	if _, ok := d.enqueuedAt[task.Identifier]; !ok {
+		d.enqueuedAt[task.Identifier] = d.now()
+	}
+
+	d.scheduler.AddTask(task)
+	d.cond.Signal()
+}
+
+func (d *Dispatcher) tryPreempt(incoming Task) {
+	if d.preempt == nil || len(d.running) < d.workers {
+		return
+	}
+
+	var victim *runningTask
+	for _, running := range d.running {
+		if running.preempted {
+			continue
+		}
+		if victim == nil || running.task.Priority < victim.task.Priority {
+			victim = running
+		}
+	}
+
+	if victim != nil && d.preempt(victim.task, incoming) {
+		victim.preempted = true
+		victim.cancel(ErrPreempted)
+	}
+}
+
+func runDispatcher(t *testing.T, dispatcher *Dispatcher) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("dispatcher didn't stop")
+		}
+	}
+}
+
+func TestDispatcherRunsHighestPriorityFirst(t *testing.T) {
+	release := make(chan struct{})
+	executed := make(chan int, 10)
+
+	dispatcher := NewDispatcher(1, func(ctx context.Context, task Task) error {
+		if task.Identifier == 0 {
+			<-release
+		}
+		executed <- task.Identifier
+		return nil
+	})
+
+	stop := runDispatcher(t, dispatcher)
+	defer stop()
+
+	// the only worker is busy while the rest of tasks is submitted
+	dispatcher.Submit(Task{Identifier: 0, Priority: 0})
+	require.Eventually(t, func() bool {
+		return dispatcher.Stats().Running == 1
+	}, time.Second, time.Millisecond)
+
+	for i := 1; i <= 5; i++ {
+		dispatcher.Submit(Task{Identifier: i, Priority: i * 10})
+	}
+	close(release)
+
+	assert.Equal(t, 0, <-executed)
+	for i := 5; i > 0; i-- {
+		assert.Equal(t, i, <-executed)
+	}
+}
+
+func TestDispatcherWaitsForWork(t *testing.T) {
+	var mutex sync.Mutex
+	executed := make(map[int]bool)
+
+	dispatcher := NewDispatcher(4, func(ctx context.Context, task Task) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		executed[task.Identifier] = true
+		return nil
+	})
+
+	stop := runDispatcher(t, dispatcher)
+	defer stop()
+
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		dispatcher.Submit(Task{Identifier: i, Priority: i % 3})
+	}
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(executed) == 100
+	}, time.Second, time.Millisecond)
+}
+
+func TestDispatcherPreemption(t *testing.T) {
+	executed := make(chan string, 10)
+
+	dispatcher := NewDispatcher(1, func(ctx context.Context, task Task) error {
+		if task.Identifier != 1 {
+			executed <- "short"
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			executed <- "long preempted"
+			return context.Cause(ctx)
+		case <-time.After(50 * time.Millisecond):
+			executed <- "long finished"
+			return nil
+		}
+	})
+
+	dispatcher.SetPreemptionHook(func(running Task, incoming Task) bool {
+		return incoming.Priority > running.Priority
+	})
+
+	stop := runDispatcher(t, dispatcher)
+	defer stop()
+
+	dispatcher.Submit(Task{Identifier: 1, Priority: 1})
+	require.Eventually(t, func() bool {
+		return dispatcher.Stats().Running == 1
+	}, time.Second, time.Millisecond)
+
+	// the same priority doesn't preempt
+	dispatcher.Submit(Task{Identifier: 2, Priority: 1})
+	dispatcher.Submit(Task{Identifier: 3, Priority: 10})
+
+	// the preempted task goes back behind the task of the same priority
+	assert.Equal(t, "long preempted", <-executed)
+	assert.Equal(t, "short", <-executed)
+	assert.Equal(t, "short", <-executed)
+	assert.Equal(t, "long finished", <-executed)
+}
+
+func TestDispatcherStats(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dispatcher := NewDispatcher(1, func(ctx context.Context, task Task) error {
+		return nil
+	})
+	dispatcher.now = func() time.Time { return now }
+
+	dispatcher.Submit(Task{Identifier: 1, Priority: 1})
+	dispatcher.Submit(Task{Identifier: 2, Priority: 1})
+	dispatcher.Submit(Task{Identifier: 3, Priority: 5})
+
+	stats := dispatcher.Stats()
+	assert.Equal(t, 3, stats.QueueDepth)
+	assert.Equal(t, 2, stats.Priorities[1].Queued)
+	assert.Equal(t, 1, stats.Priorities[5].Queued)
+
+	now = now.Add(time.Second)
+	stop := runDispatcher(t, dispatcher)
+	require.Eventually(t, func() bool {
+		return dispatcher.Stats().QueueDepth == 0
+	}, time.Second, time.Millisecond)
+	stop()
+
+	stats = dispatcher.Stats()
+	assert.Equal(t, 0, stats.Priorities[1].Queued)
+	assert.Equal(t, 2, stats.Priorities[1].Dispatched)
+	assert.Equal(t, time.Second, stats.Priorities[1].AverageWait())
+	assert.Equal(t, time.Second, stats.Priorities[5].MaxWait)
+}
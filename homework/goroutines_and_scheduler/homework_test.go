@@ -2,18 +2,48 @@ package main
 
 import (
 	"container/heap"
-	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
+// AgingPolicy returns the ordering key of a task added at the given time
+// since the scheduler start, the task with the highest key is taken first.
+// The key doesn't depend on the current time, aging is expressed as a head
+// start of earlier tasks instead, so the heap order never gets stale.
+type AgingPolicy func(priority int, enqueuedAt time.Duration) int64
+
+// LinearAging raises the priority by increment for every step of waiting,
+// so low priority tasks can't starve under a flow of high priority ones.
+// Priorities grow at the same rate, so comparing priority*step minus
+// enqueuedAt*increment gives the same order at any moment.
+// A step <= 0 means no aging.
+func LinearAging(step time.Duration, increment int) AgingPolicy {
+	return func(priority int, enqueuedAt time.Duration) int64 {
+		if step <= 0 {
+			return int64(priority)
+		}
+		return int64(priority)*int64(step) - int64(enqueuedAt)*int64(increment)
+	}
+}
+
+type queuedTask struct {
+	Task
+	key        int64
+	sequence   uint64
+	enqueuedAt time.Duration
+}
+
 type Tasks struct {
-	data    []Task
+	data    []queuedTask
 	indexes map[int]int
+	aging   AgingPolicy
 }
 
 func NewTasks() *Tasks {
 	return &Tasks{
-		data:    make([]Task, 0),
+		data:    make([]queuedTask, 0),
 		indexes: make(map[int]int),
 	}
 }
@@ -23,7 +53,12 @@ func (t *Tasks) Len() int {
 }
 
 func (t *Tasks) Less(i int, j int) bool {
-	return t.data[i].Priority > t.data[j].Priority
+	lhs, rhs := t.data[i].key, t.data[j].key
+	if lhs != rhs {
+		return lhs > rhs
+	}
+	// tasks with equal priorities are taken in the order they were added
+	return t.data[i].sequence < t.data[j].sequence
 }
 
 func (t *Tasks) Swap(i int, j int) {
@@ -33,7 +68,7 @@ func (t *Tasks) Swap(i int, j int) {
 }
 
 func (t *Tasks) Push(x any) {
-	task := x.(Task)
+	task := x.(queuedTask)
 	t.data = append(t.data, task)
 	t.indexes[task.Identifier] = len(t.data) - 1
 }
@@ -58,21 +93,41 @@ func (t *Tasks) ChangePriority(taskID, priority int) (int, bool) {
 	i, ok := t.indexes[taskID]
 	if ok {
 		t.data[i].Priority = priority
+		t.data[i].key = t.key(priority, t.data[i].enqueuedAt)
 	}
 	return i, ok
 }
 
+func (t *Tasks) key(priority int, enqueuedAt time.Duration) int64 {
+	if t.aging == nil {
+		return int64(priority)
+	}
+	return t.aging(priority, enqueuedAt)
+}
+
 type Task struct {
 	Identifier int
 	Priority   int
 }
 
 type Scheduler struct {
-	tasks *Tasks
+	tasks    *Tasks
+	sequence uint64
+	now      func() time.Time
+	// start is the moment the first task was added
+	start time.Time
 }
 
 func NewScheduler() Scheduler {
-	return Scheduler{tasks: NewTasks()}
+	return Scheduler{tasks: NewTasks(), now: time.Now}
+}
+
+// NewSchedulerWithAging creates a scheduler which orders
+// tasks by their effective priority according to aging.
+func NewSchedulerWithAging(aging AgingPolicy) Scheduler {
+	scheduler := NewScheduler()
+	scheduler.tasks.aging = aging
+	return scheduler
 }
 
 func (s *Scheduler) AddTask(task Task) {
@@ -80,10 +135,19 @@ func (s *Scheduler) AddTask(task Task) {
 		// if task already exists - change its priority instead of adding new
 		s.ChangeTaskPriority(task.Identifier, task.Priority)
 	} else {
-		heap.Push(s.tasks, task)
+		s.sequence++
+		enqueuedAt := s.elapsed()
+		heap.Push(s.tasks, queuedTask{
+			Task:       task,
+			key:        s.tasks.key(task.Priority, enqueuedAt),
+			sequence:   s.sequence,
+			enqueuedAt: enqueuedAt,
+		})
 	}
 }
 
+// ChangeTaskPriority keeps the time the task was added,
+// so the task doesn't lose the priority gained by aging.
 func (s *Scheduler) ChangeTaskPriority(taskID int, newPriority int) {
 	i, ok := s.tasks.ChangePriority(taskID, newPriority)
 	if ok {
@@ -91,12 +155,22 @@ func (s *Scheduler) ChangeTaskPriority(taskID int, newPriority int) {
 	}
 }
 
-func (s *Scheduler) GetTask() Task {
+func (s *Scheduler) elapsed() time.Duration {
+	now := s.now()
+	if s.start.IsZero() {
+		s.start = now
+	}
+	return now.Sub(s.start)
+}
+
+// GetTask returns the task with the highest effective priority,
+// ok is false when there are no tasks.
+func (s *Scheduler) GetTask() (Task, bool) {
 	if s.tasks.Empty() {
-		// looks like it might be better to return nil here, but i decided to save contract
-		return Task{}
+		return Task{}, false
 	}
-	return heap.Pop(s.tasks).(Task)
+
+	return heap.Pop(s.tasks).(queuedTask).Task, true
 }
 
 func TestScheduler(t *testing.T) {
@@ -113,19 +187,20 @@ func TestScheduler(t *testing.T) {
 	scheduler.AddTask(task4)
 	scheduler.AddTask(task5)
 
-	task := scheduler.GetTask()
+	task, ok := scheduler.GetTask()
+	assert.True(t, ok)
 	assert.Equal(t, task5, task)
 
-	task = scheduler.GetTask()
+	task, _ = scheduler.GetTask()
 	assert.Equal(t, task4, task)
 
 	scheduler.ChangeTaskPriority(1, 100)
 
-	task = scheduler.GetTask()
+	task, _ = scheduler.GetTask()
 	// looks like a bug we need to have priority like 100 here, because we changed it upper
 	assert.Equal(t, Task{Identifier: 1, Priority: 100}, task)
 
-	task = scheduler.GetTask()
+	task, _ = scheduler.GetTask()
 	assert.Equal(t, task3, task)
 }
 
@@ -135,7 +210,8 @@ func TestSchedulerAddHighPriorities(t *testing.T) {
 		scheduler.AddTask(Task{Identifier: i, Priority: i * 10})
 	}
 	for i := 100; i > 0; i-- {
-		task := scheduler.GetTask()
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
 		assert.Equal(t, Task{Identifier: i, Priority: i * 10}, task)
 	}
 }
@@ -146,7 +222,8 @@ func TestSchedulerAddLowPriorities(t *testing.T) {
 		scheduler.AddTask(Task{Identifier: i, Priority: i * 10})
 	}
 	for i := 100; i > 0; i-- {
-		task := scheduler.GetTask()
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
 		assert.Equal(t, Task{Identifier: i, Priority: i * 10}, task)
 	}
 }
@@ -160,13 +237,14 @@ func TestChangeTaskPriority(t *testing.T) {
 	scheduler.ChangeTaskPriority(2, 50)
 	scheduler.ChangeTaskPriority(1, 40)
 
-	task := scheduler.GetTask()
+	task, ok := scheduler.GetTask()
+	assert.True(t, ok)
 	assert.Equal(t, Task{Identifier: 2, Priority: 50}, task)
 
-	task = scheduler.GetTask()
+	task, _ = scheduler.GetTask()
 	assert.Equal(t, Task{Identifier: 1, Priority: 40}, task)
 
-	task = scheduler.GetTask()
+	task, _ = scheduler.GetTask()
 	assert.Equal(t, Task{Identifier: 3, Priority: 30}, task)
 }
 
@@ -176,6 +254,117 @@ func TestAddDuplicate(t *testing.T) {
 	scheduler.AddTask(Task{Identifier: 1, Priority: 15})
 	scheduler.AddTask(Task{Identifier: 1, Priority: 5})
 
-	task := scheduler.GetTask()
+	task, ok := scheduler.GetTask()
+	assert.True(t, ok)
 	assert.Equal(t, Task{Identifier: 1, Priority: 5}, task)
 }
+
+func TestGetTaskFromEmptyScheduler(t *testing.T) {
+	scheduler := NewScheduler()
+	_, ok := scheduler.GetTask()
+	assert.False(t, ok)
+
+	scheduler.AddTask(Task{Identifier: 1, Priority: 10})
+	_, ok = scheduler.GetTask()
+	assert.True(t, ok)
+
+	_, ok = scheduler.GetTask()
+	assert.False(t, ok)
+}
+
+func TestSchedulerEqualPrioritiesInFIFOOrder(t *testing.T) {
+	scheduler := NewScheduler()
+	for i := 1; i <= 50; i++ {
+		scheduler.AddTask(Task{Identifier: i, Priority: i % 2})
+	}
+
+	for priority := 1; priority >= 0; priority-- {
+		previous := 0
+		for i := 0; i < 25; i++ {
+			task, ok := scheduler.GetTask()
+			assert.True(t, ok)
+			assert.Equal(t, priority, task.Priority)
+			assert.Greater(t, task.Identifier, previous)
+			previous = task.Identifier
+		}
+	}
+}
+
+func TestSchedulerWithAging(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduler := NewSchedulerWithAging(LinearAging(time.Second, 10))
+	scheduler.now = func() time.Time { return now }
+
+	scheduler.AddTask(Task{Identifier: 1, Priority: 0})
+
+	// a flow of high priority tasks doesn't starve the old one
+	for i := 2; i <= 5; i++ {
+		now = now.Add(time.Second)
+		scheduler.AddTask(Task{Identifier: i, Priority: 50})
+
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
+		assert.Equal(t, Task{Identifier: i, Priority: 50}, task)
+	}
+
+	now = now.Add(time.Second)
+	scheduler.AddTask(Task{Identifier: 6, Priority: 50})
+
+	task, ok := scheduler.GetTask()
+	assert.True(t, ok)
+	assert.Equal(t, Task{Identifier: 1, Priority: 0}, task)
+
+	task, _ = scheduler.GetTask()
+	assert.Equal(t, Task{Identifier: 6, Priority: 50}, task)
+}
+
+func TestSchedulerWithoutAgingStarvesLowPriority(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler()
+	scheduler.now = func() time.Time { return now }
+
+	scheduler.AddTask(Task{Identifier: 1, Priority: 0})
+	for i := 2; i <= 100; i++ {
+		now = now.Add(time.Hour)
+		scheduler.AddTask(Task{Identifier: i, Priority: 50})
+
+		task, _ := scheduler.GetTask()
+		assert.Equal(t, Task{Identifier: i, Priority: 50}, task)
+	}
+}
+
+func TestLinearAgingWithoutStep(t *testing.T) {
+	now := time.Now()
+	scheduler := NewSchedulerWithAging(LinearAging(0, 10))
+	scheduler.now = func() time.Time { return now }
+
+	scheduler.AddTask(Task{Identifier: 1, Priority: 0})
+	scheduler.AddTask(Task{Identifier: 2, Priority: 5})
+	now = now.Add(time.Hour)
+
+	task, ok := scheduler.GetTask()
+	assert.True(t, ok)
+	assert.Equal(t, Task{Identifier: 2, Priority: 5}, task)
+}
+
+func TestSchedulerAgingKeepsOrderWithoutRebuilding(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduler := NewSchedulerWithAging(LinearAging(time.Second, 10))
+	scheduler.now = func() time.Time { return now }
+
+	scheduler.AddTask(Task{Identifier: 1, Priority: 0})
+	scheduler.AddTask(Task{Identifier: 2, Priority: 20})
+	now = now.Add(3 * time.Second)
+	scheduler.AddTask(Task{Identifier: 3, Priority: 45})
+
+	// after 3 seconds the effective priorities are 30, 50 and 45
+	scheduler.ChangeTaskPriority(1, 25)
+	now = now.Add(time.Hour)
+
+	// 1 has 25+30, so the aging isn't lost when the priority changes
+	for _, identifier := range []int{1, 2, 3} {
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
+		assert.Equal(t, identifier, task.Identifier)
+	}
+}
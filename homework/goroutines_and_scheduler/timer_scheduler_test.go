@@ -0,0 +1,287 @@
+package main
+
+import (
+	"container/heap"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type timer struct {
+	task     Task
+	deadline time.Time
+	interval time.Duration
+	sequence uint64
+}
+
+// Timers is a min-heap of timers by deadline
+type Timers struct {
+	data    []timer
+	indexes map[int]int
+}
+
+func NewTimers() *Timers {
+	return &Timers{
+		data:    make([]timer, 0),
+		indexes: make(map[int]int),
+	}
+}
+
+func (t *Timers) Len() int {
+	return len(t.data)
+}
+
+func (t *Timers) Less(i int, j int) bool {
+	if !t.data[i].deadline.Equal(t.data[j].deadline) {
+		return t.data[i].deadline.Before(t.data[j].deadline)
+	}
+	return t.data[i].sequence < t.data[j].sequence
+}
+
+func (t *Timers) Swap(i int, j int) {
+	t.data[i], t.data[j] = t.data[j], t.data[i]
+	t.indexes[t.data[i].task.Identifier] = i
+	t.indexes[t.data[j].task.Identifier] = j
+}
+
+func (t *Timers) Push(x any) {
+	el := x.(timer)
+	t.data = append(t.data, el)
+	t.indexes[el.task.Identifier] = len(t.data) - 1
+}
+
+func (t *Timers) Pop() any {
+	el := t.data[len(t.data)-1]
+	t.data = t.data[:len(t.data)-1]
+	delete(t.indexes, el.task.Identifier)
+	return el
+}
+
+// TimerScheduler keeps delayed and periodic tasks until they
+// are due and then hands them over to the priority Scheduler.
+// Time only moves forward on Tick, so nothing runs in background.
+type TimerScheduler struct {
+	scheduler *Scheduler
+	clock     Clock
+	timers    *Timers
+	sequence  uint64
+}
+
+func NewTimerScheduler(scheduler *Scheduler, clock Clock) *TimerScheduler {
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &TimerScheduler{
+		scheduler: scheduler,
+		clock:     clock,
+		timers:    NewTimers(),
+	}
+}
+
+// Schedule adds the task to the scheduler at the given moment, a task
+// with the same identifier that is already waiting is rescheduled.
+func (s *TimerScheduler) Schedule(task Task, at time.Time) {
+	s.add(timer{task: task, deadline: at})
+}
+
+// Every adds the task to the scheduler every interval, starting
+// one interval from now, until it is cancelled.
+func (s *TimerScheduler) Every(task Task, interval time.Duration) {
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+
+	s.add(timer{task: task, deadline: s.clock.Now().Add(interval), interval: interval})
+}
+
+// Cancel removes a waiting task, tasks that were already passed
+// to the scheduler are not affected.
+func (s *TimerScheduler) Cancel(taskID int) bool {
+	i, ok := s.timers.indexes[taskID]
+	if ok {
+		heap.Remove(s.timers, i)
+	}
+	return ok
+}
+
+// NextDeadline reports when Tick should be called next.
+func (s *TimerScheduler) NextDeadline() (time.Time, bool) {
+	if s.timers.Len() == 0 {
+		return time.Time{}, false
+	}
+	return s.timers.data[0].deadline, true
+}
+
+// Tick moves all due tasks into the scheduler and returns their number.
+// A periodic task that missed several intervals is added only once.
+func (s *TimerScheduler) Tick() int {
+	now := s.clock.Now()
+
+	due := 0
+	for s.timers.Len() != 0 && !s.timers.data[0].deadline.After(now) {
+		next := heap.Pop(s.timers).(timer)
+		s.scheduler.AddTask(next.task)
+		due++
+
+		if next.interval > 0 {
+			missed := now.Sub(next.deadline) / next.interval
+			next.deadline = next.deadline.Add((missed + 1) * next.interval)
+			s.add(next)
+		}
+	}
+
+	return due
+}
+
+func (s *TimerScheduler) add(next timer) {
+	s.Cancel(next.task.Identifier)
+
+	s.sequence++
+	next.sequence = s.sequence
+	heap.Push(s.timers, next)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(duration time.Duration) {
+	c.now = c.now.Add(duration)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestTimerSchedulerSchedule(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewScheduler()
+	timers := NewTimerScheduler(&scheduler, clock)
+
+	timers.Schedule(Task{Identifier: 1, Priority: 10}, clock.Now().Add(2*time.Second))
+	timers.Schedule(Task{Identifier: 2, Priority: 20}, clock.Now().Add(time.Second))
+
+	deadline, ok := timers.NextDeadline()
+	assert.True(t, ok)
+	assert.Equal(t, clock.Now().Add(time.Second), deadline)
+
+	assert.Equal(t, 0, timers.Tick())
+	_, ok = scheduler.GetTask()
+	assert.False(t, ok)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, timers.Tick())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, timers.Tick())
+
+	_, ok = timers.NextDeadline()
+	assert.False(t, ok)
+
+	// both are due now, so the priority decides
+	task, _ := scheduler.GetTask()
+	assert.Equal(t, Task{Identifier: 2, Priority: 20}, task)
+	task, _ = scheduler.GetTask()
+	assert.Equal(t, Task{Identifier: 1, Priority: 10}, task)
+}
+
+func TestTimerSchedulerPriorityAmongDueTasks(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewScheduler()
+	timers := NewTimerScheduler(&scheduler, clock)
+
+	for i := 1; i <= 10; i++ {
+		timers.Schedule(Task{Identifier: i, Priority: i}, clock.Now().Add(time.Duration(i)*time.Millisecond))
+	}
+
+	clock.Advance(5 * time.Millisecond)
+	assert.Equal(t, 5, timers.Tick())
+
+	for i := 5; i > 0; i-- {
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
+		assert.Equal(t, i, task.Identifier)
+	}
+}
+
+func TestTimerSchedulerEvery(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewScheduler()
+	timers := NewTimerScheduler(&scheduler, clock)
+
+	timers.Every(Task{Identifier: 1, Priority: 10}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(59 * time.Second)
+		assert.Equal(t, 0, timers.Tick())
+
+		clock.Advance(time.Second)
+		assert.Equal(t, 1, timers.Tick())
+
+		task, ok := scheduler.GetTask()
+		assert.True(t, ok)
+		assert.Equal(t, Task{Identifier: 1, Priority: 10}, task)
+	}
+
+	// missed intervals are collapsed into one run
+	start := clock.Now()
+	clock.Advance(5*time.Minute + 30*time.Second)
+	assert.Equal(t, 1, timers.Tick())
+
+	deadline, _ := timers.NextDeadline()
+	assert.Equal(t, start.Add(6*time.Minute), deadline)
+}
+
+func TestTimerSchedulerCancel(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewScheduler()
+	timers := NewTimerScheduler(&scheduler, clock)
+
+	timers.Schedule(Task{Identifier: 1, Priority: 10}, clock.Now().Add(time.Second))
+	timers.Every(Task{Identifier: 2, Priority: 10}, time.Second)
+	timers.Schedule(Task{Identifier: 3, Priority: 10}, clock.Now().Add(time.Second))
+
+	assert.True(t, timers.Cancel(1))
+	assert.True(t, timers.Cancel(2))
+	assert.False(t, timers.Cancel(2))
+	assert.False(t, timers.Cancel(4))
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, 1, timers.Tick())
+
+	task, _ := scheduler.GetTask()
+	assert.Equal(t, 3, task.Identifier)
+	_, ok := scheduler.GetTask()
+	assert.False(t, ok)
+}
+
+func TestTimerSchedulerReschedule(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewScheduler()
+	timers := NewTimerScheduler(&scheduler, clock)
+
+	timers.Schedule(Task{Identifier: 1, Priority: 10}, clock.Now().Add(time.Second))
+	timers.Schedule(Task{Identifier: 1, Priority: 10}, clock.Now().Add(time.Minute))
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 0, timers.Tick())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 1, timers.Tick())
+}
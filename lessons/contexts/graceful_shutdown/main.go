@@ -33,7 +33,7 @@ func main() {
 	<-ctx.Done()
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	cancel()
+	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Print(err.Error())
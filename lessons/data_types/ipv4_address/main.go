@@ -26,7 +26,6 @@ func Convert(address string) (uint32, error) {
 
 		bitOffset := (octetsCount - idx - 1) * 8
 		result |= uint32(number << bitOffset)
-		fmt.Printf("%08b = %d\n", number, number)
 	}
 
 	return result, nil
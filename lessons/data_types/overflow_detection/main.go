@@ -42,11 +42,11 @@ func Mul(lhs, rhs int) (int, error) {
 		return 0, ErrIntOverflow
 	}
 
-	if lhs > math.MaxInt/rhs {
-		return 0, ErrIntOverflow
-	} else if lhs < math.MinInt/rhs {
+	// comparing with math.MaxInt/rhs breaks for negative rhs
+	result := lhs * rhs
+	if result/rhs != lhs {
 		return 0, ErrIntOverflow
 	}
 
-	return lhs * rhs, nil
+	return result, nil
 }
@@ -16,11 +16,11 @@ func NewOptional[T any](value T) Optional[T] {
 	}
 }
 
-func (o *Optional[T]) HasValue() bool {
+func (o Optional[T]) HasValue() bool {
 	return o.present
 }
 
-func (o *Optional[T]) Value() T {
+func (o Optional[T]) Value() T {
 	return o.value
 }
 
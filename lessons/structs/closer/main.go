@@ -5,7 +5,7 @@ type Closer struct {
 }
 
 func (c *Closer) Add(action func()) {
-	if action != nil {
+	if action == nil {
 		return
 	}
 
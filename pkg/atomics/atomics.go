@@ -0,0 +1,130 @@
+package atomics
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"golang_course/pkg/checked"
+)
+
+// ErrIntOverflow is checked.ErrIntOverflow, so both packages report the same error.
+var ErrIntOverflow = checked.ErrIntOverflow
+
+type Integer interface {
+	~int32 | ~int64 | ~uint32 | ~uint64 | ~uintptr
+}
+
+func Load[T Integer](addr *T) T {
+	switch unsafe.Sizeof(*addr) {
+	case 4:
+		return T(atomic.LoadUint32((*uint32)(unsafe.Pointer(addr))))
+	default:
+		return T(atomic.LoadUint64((*uint64)(unsafe.Pointer(addr))))
+	}
+}
+
+func Store[T Integer](addr *T, value T) {
+	switch unsafe.Sizeof(*addr) {
+	case 4:
+		atomic.StoreUint32((*uint32)(unsafe.Pointer(addr)), uint32(value))
+	default:
+		atomic.StoreUint64((*uint64)(unsafe.Pointer(addr)), uint64(value))
+	}
+}
+
+func CompareAndSwap[T Integer](addr *T, old, new T) bool {
+	switch unsafe.Sizeof(*addr) {
+	case 4:
+		return atomic.CompareAndSwapUint32((*uint32)(unsafe.Pointer(addr)), uint32(old), uint32(new))
+	default:
+		return atomic.CompareAndSwapUint64((*uint64)(unsafe.Pointer(addr)), uint64(old), uint64(new))
+	}
+}
+
+// UpdateAndGet atomically replaces the value with fn(current)
+// and returns the new value. fn may be called several times
+// under contention, so it must be free of side effects.
+func UpdateAndGet[T Integer](addr *T, fn func(T) T) T {
+	for {
+		currentValue := Load(addr)
+		nextValue := fn(currentValue)
+		if CompareAndSwap(addr, currentValue, nextValue) {
+			return nextValue
+		}
+	}
+}
+
+// GetAndUpdate is like UpdateAndGet but returns the previous value.
+func GetAndUpdate[T Integer](addr *T, fn func(T) T) T {
+	for {
+		currentValue := Load(addr)
+		nextValue := fn(currentValue)
+		if CompareAndSwap(addr, currentValue, nextValue) {
+			return currentValue
+		}
+	}
+}
+
+// AccumulateAndGet atomically replaces the value with fn(current, x)
+// and returns the new value.
+func AccumulateAndGet[T Integer](addr *T, x T, fn func(T, T) T) T {
+	return UpdateAndGet(addr, func(current T) T {
+		return fn(current, x)
+	})
+}
+
+// GetAndAccumulate is like AccumulateAndGet but returns the previous value.
+func GetAndAccumulate[T Integer](addr *T, x T, fn func(T, T) T) T {
+	return GetAndUpdate(addr, func(current T) T {
+		return fn(current, x)
+	})
+}
+
+// TryUpdateAndGet is like UpdateAndGet, but stops without storing
+// anything as soon as fn returns an error.
+func TryUpdateAndGet[T Integer](addr *T, fn func(T) (T, error)) (T, error) {
+	for {
+		currentValue := Load(addr)
+		nextValue, err := fn(currentValue)
+		if err != nil {
+			return currentValue, err
+		}
+		if CompareAndSwap(addr, currentValue, nextValue) {
+			return nextValue, nil
+		}
+	}
+}
+
+func IncrementAndGet[T Integer](addr *T) T {
+	return UpdateAndGet(addr, func(current T) T {
+		return current + 1
+	})
+}
+
+// CheckedIncrementAndGet increments the value unless it is already
+// at the maximum of T, in which case ErrIntOverflow is returned
+// and the value is left untouched.
+func CheckedIncrementAndGet[T Integer](addr *T) (T, error) {
+	return CheckedAddAndGet(addr, 1)
+}
+
+// CheckedAddAndGet adds delta unless the result would overflow T.
+func CheckedAddAndGet[T Integer](addr *T, delta T) (T, error) {
+	return TryUpdateAndGet(addr, func(current T) (T, error) {
+		return checked.Add(current, delta)
+	})
+}
+
+// BoundedAddAndGet adds delta unless the result would leave [lower, upper].
+func BoundedAddAndGet[T Integer](addr *T, delta, lower, upper T) (T, error) {
+	return TryUpdateAndGet(addr, func(current T) (T, error) {
+		next, err := checked.Add(current, delta)
+		if err != nil {
+			return 0, err
+		}
+		if next < lower || next > upper {
+			return 0, ErrIntOverflow
+		}
+		return next, nil
+	})
+}
@@ -0,0 +1,187 @@
+package atomics
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang_course/pkg/checked"
+)
+
+func TestUpdateAndGet(t *testing.T) {
+	var value int32 = 10
+	result := UpdateAndGet(&value, func(current int32) int32 {
+		return current * 2
+	})
+
+	assert.Equal(t, int32(20), result)
+	assert.Equal(t, int32(20), value)
+}
+
+func TestGetAndUpdate(t *testing.T) {
+	var value uint64 = 10
+	result := GetAndUpdate(&value, func(current uint64) uint64 {
+		return current * 2
+	})
+
+	assert.Equal(t, uint64(10), result)
+	assert.Equal(t, uint64(20), value)
+}
+
+func TestAccumulateAndGet(t *testing.T) {
+	var value int64 = 3
+	maxFn := func(lhs, rhs int64) int64 {
+		return max(lhs, rhs)
+	}
+
+	assert.Equal(t, int64(7), AccumulateAndGet(&value, 7, maxFn))
+	assert.Equal(t, int64(7), AccumulateAndGet(&value, 5, maxFn))
+	assert.Equal(t, int64(7), GetAndAccumulate(&value, 9, maxFn))
+	assert.Equal(t, int64(9), value)
+}
+
+func TestIncrementAndGetConcurrently(t *testing.T) {
+	const goroutines = 100
+	const increments = 1000
+
+	var value int32
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				IncrementAndGet(&value)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(goroutines*increments), value)
+}
+
+func TestCheckedIncrementAndGet(t *testing.T) {
+	var signed int32 = math.MaxInt32 - 1
+	result, err := CheckedIncrementAndGet(&signed)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), result)
+
+	_, err = CheckedIncrementAndGet(&signed)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	assert.ErrorIs(t, err, checked.ErrIntOverflow)
+	assert.Equal(t, int32(math.MaxInt32), signed)
+
+	var unsigned uint32 = math.MaxUint32
+	_, err = CheckedIncrementAndGet(&unsigned)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	assert.Equal(t, uint32(math.MaxUint32), unsigned)
+}
+
+func TestCheckedAddAndGet(t *testing.T) {
+	tests := map[string]struct {
+		value    int64
+		delta    int64
+		result   int64
+		overflow bool
+	}{
+		"positive":          {value: 1, delta: 2, result: 3},
+		"negative":          {value: 1, delta: -2, result: -1},
+		"max":               {value: math.MaxInt64 - 5, delta: 5, result: math.MaxInt64},
+		"min":               {value: math.MinInt64 + 5, delta: -5, result: math.MinInt64},
+		"positive overflow": {value: math.MaxInt64 - 5, delta: 6, overflow: true},
+		"negative overflow": {value: math.MinInt64 + 5, delta: -6, overflow: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			value := test.value
+			result, err := CheckedAddAndGet(&value, test.delta)
+			if test.overflow {
+				assert.ErrorIs(t, err, ErrIntOverflow)
+				assert.Equal(t, test.value, value)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.result, result)
+				assert.Equal(t, test.result, value)
+			}
+		})
+	}
+}
+
+func TestBoundedAddAndGetConcurrently(t *testing.T) {
+	const limit = 100
+
+	var value int32
+	var succeeded atomic.Int32
+
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := BoundedAddAndGet(&value, 1, 0, limit); err == nil {
+					succeeded.Add(1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(limit), value)
+	assert.Equal(t, int32(limit), succeeded.Load())
+}
+
+func TestLongAdder(t *testing.T) {
+	var adder LongAdder
+	goroutines := runtime.NumCPU() * 4
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				adder.Increment()
+			}
+			adder.Add(10)
+			adder.Decrement()
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(goroutines*1009), adder.Sum())
+	assert.Equal(t, int64(goroutines*1009), adder.SumThenReset())
+	assert.Equal(t, int64(0), adder.Sum())
+}
+
+func TestPaddedSize(t *testing.T) {
+	assert.Equal(t, uintptr(CacheLineSize), unsafe.Sizeof(PaddedInt64{}))
+	assert.Equal(t, uintptr(CacheLineSize), unsafe.Sizeof(PaddedUint64{}))
+}
+
+// go test -bench=. ./pkg/atomics
+
+func BenchmarkAtomicInt64(b *testing.B) {
+	var counter atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Add(1)
+		}
+	})
+}
+
+func BenchmarkLongAdder(b *testing.B) {
+	var counter LongAdder
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Increment()
+		}
+	})
+}
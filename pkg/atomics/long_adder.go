@@ -0,0 +1,102 @@
+package atomics
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CacheLineSize is large enough for common amd64 and arm64 CPUs
+// (arm64 Apple chips use 128 byte lines).
+const CacheLineSize = 128
+
+// CacheLinePad is placed between hot fields to keep them
+// on separate cache lines and avoid false sharing.
+type CacheLinePad struct {
+	_ [CacheLineSize]byte
+}
+
+type PaddedInt64 struct {
+	atomic.Int64
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Int64{})]byte
+}
+
+type PaddedUint64 struct {
+	atomic.Uint64
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uint64{})]byte
+}
+
+// LongAdder is a counter for highly contended writes. Updates go
+// to the base value until a CAS on it fails, after that they are
+// spread across padded cells and Sum folds everything together.
+// The zero value is ready to use.
+type LongAdder struct {
+	base  PaddedInt64
+	cells atomic.Pointer[[]PaddedInt64]
+}
+
+func (a *LongAdder) Add(delta int64) {
+	cells := a.cells.Load()
+	if cells == nil {
+		current := a.base.Load()
+		if a.base.CompareAndSwap(current, current+delta) {
+			return
+		}
+		cells = a.initCells()
+	}
+
+	(*cells)[rand.Uint32()&uint32(len(*cells)-1)].Add(delta)
+}
+
+func (a *LongAdder) Increment() {
+	a.Add(1)
+}
+
+func (a *LongAdder) Decrement() {
+	a.Add(-1)
+}
+
+// Sum is not an atomic snapshot: concurrent updates that happen
+// while the cells are being read may or may not be included.
+func (a *LongAdder) Sum() int64 {
+	sum := a.base.Load()
+	if cells := a.cells.Load(); cells != nil {
+		for idx := range *cells {
+			sum += (*cells)[idx].Load()
+		}
+	}
+
+	return sum
+}
+
+// SumThenReset is equivalent to Sum followed by Reset and,
+// like them, is only exact in the absence of concurrent updates.
+func (a *LongAdder) SumThenReset() int64 {
+	sum := a.base.Swap(0)
+	if cells := a.cells.Load(); cells != nil {
+		for idx := range *cells {
+			sum += (*cells)[idx].Swap(0)
+		}
+	}
+
+	return sum
+}
+
+func (a *LongAdder) Reset() {
+	a.SumThenReset()
+}
+
+func (a *LongAdder) initCells() *[]PaddedInt64 {
+	size := 1
+	for size < runtime.GOMAXPROCS(0) {
+		size <<= 1
+	}
+
+	cells := make([]PaddedInt64, size)
+	if a.cells.CompareAndSwap(nil, &cells) {
+		return &cells
+	}
+
+	return a.cells.Load()
+}
@@ -0,0 +1,211 @@
+// Package bitset implements a growable set of non-negative integers
+// stored as bits of []uint64 and typed flags for bit masks.
+package bitset
+
+import (
+	"iter"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const wordSize = 64
+
+// BitSet grows when a bit beyond its length is set.
+// The zero value is an empty set.
+type BitSet struct {
+	words []uint64
+}
+
+// New returns a set with room for size bits.
+func New(size uint) *BitSet {
+	return &BitSet{words: make([]uint64, wordsFor(size))}
+}
+
+func wordsFor(size uint) int {
+	return int((size + wordSize - 1) / wordSize)
+}
+
+func (b *BitSet) grow(index uint) {
+	if needed := int(index/wordSize) + 1; needed > len(b.words) {
+		b.words = append(b.words, make([]uint64, needed-len(b.words))...)
+	}
+}
+
+// Len returns the number of bits the set can hold without growing.
+func (b *BitSet) Len() uint {
+	return uint(len(b.words)) * wordSize
+}
+
+func (b *BitSet) Set(index uint) {
+	b.grow(index)
+	b.words[index/wordSize] |= 1 << (index % wordSize)
+}
+
+func (b *BitSet) Clear(index uint) {
+	if index < b.Len() {
+		b.words[index/wordSize] &^= 1 << (index % wordSize)
+	}
+}
+
+func (b *BitSet) Flip(index uint) {
+	b.grow(index)
+	b.words[index/wordSize] ^= 1 << (index % wordSize)
+}
+
+func (b *BitSet) Test(index uint) bool {
+	return index < b.Len() && b.words[index/wordSize]&(1<<(index%wordSize)) != 0
+}
+
+// Count returns the number of set bits.
+func (b *BitSet) Count() int {
+	count := 0
+	for _, word := range b.words {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+func (b *BitSet) IsEmpty() bool {
+	for _, word := range b.words {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// NextSet returns the first set bit at or after from.
+func (b *BitSet) NextSet(from uint) (uint, bool) {
+	idx := int(from / wordSize)
+	if idx >= len(b.words) {
+		return 0, false
+	}
+
+	// ignore the bits below from in the first word
+	word := b.words[idx] >> (from % wordSize)
+	if word != 0 {
+		return from + uint(bits.TrailingZeros64(word)), true
+	}
+
+	for idx++; idx < len(b.words); idx++ {
+		if b.words[idx] != 0 {
+			return uint(idx)*wordSize + uint(bits.TrailingZeros64(b.words[idx])), true
+		}
+	}
+	return 0, false
+}
+
+// NextClear returns the first clear bit at or after from,
+// which always exists because the set can grow.
+func (b *BitSet) NextClear(from uint) uint {
+	idx := int(from / wordSize)
+	if idx >= len(b.words) {
+		return from
+	}
+
+	word := ^b.words[idx] >> (from % wordSize)
+	if word != 0 {
+		return from + uint(bits.TrailingZeros64(word))
+	}
+
+	for idx++; idx < len(b.words); idx++ {
+		if b.words[idx] != ^uint64(0) {
+			return uint(idx)*wordSize + uint(bits.TrailingZeros64(^b.words[idx]))
+		}
+	}
+	return b.Len()
+}
+
+// All returns an iterator over the set bits in ascending order.
+func (b *BitSet) All() iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		for idx, word := range b.words {
+			for word != 0 {
+				if !yield(uint(idx)*wordSize + uint(bits.TrailingZeros64(word))) {
+					return
+				}
+				word &= word - 1
+			}
+		}
+	}
+}
+
+func (b *BitSet) Clone() *BitSet {
+	return &BitSet{words: append([]uint64(nil), b.words...)}
+}
+
+// Equal compares the set bits, the lengths may differ.
+func (b *BitSet) Equal(other *BitSet) bool {
+	for idx := range max(len(b.words), len(other.words)) {
+		if b.word(idx) != other.word(idx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BitSet) word(idx int) uint64 {
+	if idx < len(b.words) {
+		return b.words[idx]
+	}
+	return 0
+}
+
+func (b *BitSet) String() string {
+	var builder strings.Builder
+	builder.WriteByte('{')
+	for index := range b.All() {
+		if builder.Len() > 1 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(strconv.FormatUint(uint64(index), 10))
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+// The set algebra returns new sets and doesn't change the arguments.
+
+func (b *BitSet) Union(other *BitSet) *BitSet {
+	return b.combine(other, max(len(b.words), len(other.words)), func(x, y uint64) uint64 { return x | y })
+}
+
+func (b *BitSet) Intersection(other *BitSet) *BitSet {
+	return b.combine(other, min(len(b.words), len(other.words)), func(x, y uint64) uint64 { return x & y })
+}
+
+// Difference returns the bits set in b but not in other.
+func (b *BitSet) Difference(other *BitSet) *BitSet {
+	return b.combine(other, len(b.words), func(x, y uint64) uint64 { return x &^ y })
+}
+
+func (b *BitSet) SymmetricDifference(other *BitSet) *BitSet {
+	return b.combine(other, max(len(b.words), len(other.words)), func(x, y uint64) uint64 { return x ^ y })
+}
+
+func (b *BitSet) combine(other *BitSet, size int, op func(x, y uint64) uint64) *BitSet {
+	result := &BitSet{words: make([]uint64, size)}
+	for idx := range result.words {
+		result.words[idx] = op(b.word(idx), other.word(idx))
+	}
+	return result
+}
+
+func (b *BitSet) IsSubsetOf(other *BitSet) bool {
+	for idx, word := range b.words {
+		if word&^other.word(idx) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BitSet) Intersects(other *BitSet) bool {
+	for idx := range min(len(b.words), len(other.words)) {
+		if b.words[idx]&other.words[idx] != 0 {
+			return true
+		}
+	}
+	return false
+}
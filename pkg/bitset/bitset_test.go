@@ -0,0 +1,202 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitSet(t *testing.T) {
+	var set BitSet
+	assert.True(t, set.IsEmpty())
+	assert.False(t, set.Test(1000))
+
+	set.Set(1)
+	set.Set(64)
+	set.Set(1000)
+	assert.True(t, set.Test(1))
+	assert.True(t, set.Test(64))
+	assert.True(t, set.Test(1000))
+	assert.False(t, set.Test(63))
+	assert.Equal(t, 3, set.Count())
+	assert.Equal(t, uint(1024), set.Len())
+
+	set.Clear(64)
+	set.Clear(100000)
+	assert.False(t, set.Test(64))
+	assert.Equal(t, uint(1024), set.Len())
+
+	set.Flip(1)
+	set.Flip(2)
+	assert.False(t, set.Test(1))
+	assert.True(t, set.Test(2))
+	assert.Equal(t, "{2, 1000}", set.String())
+
+	assert.Equal(t, uint(128), New(100).Len())
+	assert.Equal(t, "{}", New(0).String())
+}
+
+func TestBitSetRandom(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	set := New(0)
+	reference := make(map[uint]bool)
+
+	for range 10000 {
+		index := random.UintN(2000)
+		switch random.IntN(3) {
+		case 0:
+			set.Set(index)
+			reference[index] = true
+		case 1:
+			set.Clear(index)
+			delete(reference, index)
+		case 2:
+			set.Flip(index)
+			if reference[index] {
+				delete(reference, index)
+			} else {
+				reference[index] = true
+			}
+		}
+	}
+
+	var expected []uint
+	for index := range reference {
+		expected = append(expected, index)
+	}
+	slices.Sort(expected)
+
+	assert.Equal(t, expected, slices.Collect(set.All()))
+	assert.Equal(t, len(expected), set.Count())
+
+	for from := uint(0); from < 2100; from++ {
+		next, ok := set.NextSet(from)
+		idx, _ := slices.BinarySearch(expected, from)
+		if idx == len(expected) {
+			assert.False(t, ok, from)
+		} else {
+			assert.True(t, ok, from)
+			assert.Equal(t, expected[idx], next, from)
+		}
+
+		free := set.NextClear(from)
+		assert.GreaterOrEqual(t, free, from)
+		assert.False(t, reference[free], from)
+		for index := from; index < free; index++ {
+			assert.True(t, reference[index], from)
+		}
+	}
+}
+
+func TestNextClearFullWords(t *testing.T) {
+	set := New(0)
+	for index := range uint(128) {
+		set.Set(index)
+	}
+
+	assert.Equal(t, uint(128), set.NextClear(0))
+	assert.Equal(t, uint(128), set.NextClear(70))
+	assert.Equal(t, uint(500), set.NextClear(500))
+
+	set.Clear(100)
+	assert.Equal(t, uint(100), set.NextClear(3))
+}
+
+func TestIterationStops(t *testing.T) {
+	set := New(0)
+	for _, index := range []uint{1, 2, 3, 200} {
+		set.Set(index)
+	}
+
+	var visited []uint
+	for index := range set.All() {
+		if index > 2 {
+			break
+		}
+		visited = append(visited, index)
+	}
+	assert.Equal(t, []uint{1, 2}, visited)
+}
+
+func setOf(indexes ...uint) *BitSet {
+	set := New(0)
+	for _, index := range indexes {
+		set.Set(index)
+	}
+	return set
+}
+
+func TestAlgebra(t *testing.T) {
+	lhs := setOf(1, 2, 3, 100, 200)
+	rhs := setOf(2, 3, 4, 300)
+
+	assert.Equal(t, "{1, 2, 3, 4, 100, 200, 300}", lhs.Union(rhs).String())
+	assert.Equal(t, "{2, 3}", lhs.Intersection(rhs).String())
+	assert.Equal(t, "{1, 100, 200}", lhs.Difference(rhs).String())
+	assert.Equal(t, "{4, 300}", rhs.Difference(lhs).String())
+	assert.Equal(t, "{1, 4, 100, 200, 300}", lhs.SymmetricDifference(rhs).String())
+
+	// the arguments are not changed
+	assert.Equal(t, "{1, 2, 3, 100, 200}", lhs.String())
+	assert.Equal(t, "{2, 3, 4, 300}", rhs.String())
+
+	assert.True(t, setOf(2, 3).IsSubsetOf(lhs))
+	assert.False(t, setOf(2, 300).IsSubsetOf(lhs))
+	assert.True(t, New(1000).IsSubsetOf(New(0)))
+	assert.True(t, lhs.Intersects(rhs))
+	assert.False(t, setOf(1).Intersects(setOf(300)))
+}
+
+func TestEqualAndClone(t *testing.T) {
+	set := setOf(1, 500)
+	clone := set.Clone()
+	assert.True(t, set.Equal(clone))
+
+	clone.Set(2)
+	assert.False(t, set.Equal(clone))
+	assert.False(t, set.Test(2))
+
+	// trailing empty words don't matter
+	grown := setOf(1)
+	grown.Set(10000)
+	grown.Clear(10000)
+	assert.True(t, grown.Equal(setOf(1)))
+	assert.True(t, setOf(1).Equal(grown))
+}
+
+type OpenMode uint8
+
+const (
+	OpenModeIn OpenMode = 1 << iota
+	OpenModeOut
+	OpenModeAppend
+	OpenModeBinary
+)
+
+func TestFlags(t *testing.T) {
+	modes := NewFlags[OpenMode]("in", "out", "append", "binary")
+	assert.Equal(t, "0", modes.String())
+
+	mode := modes.With(OpenModeIn | OpenModeBinary)
+	assert.Equal(t, OpenModeIn|OpenModeBinary, mode.Value())
+	assert.Equal(t, "in|binary", mode.String())
+	assert.Equal(t, 2, mode.Count())
+
+	// the lesson checked mask&OpenModeOut == 1, which is never true for bit 1
+	assert.True(t, mode.Has(OpenModeIn))
+	assert.True(t, mode.Has(OpenModeBinary))
+	assert.False(t, mode.Has(OpenModeOut))
+	assert.False(t, mode.Has(OpenModeIn|OpenModeOut))
+	assert.True(t, mode.HasAny(OpenModeIn|OpenModeOut))
+
+	mode = mode.Without(OpenModeIn).Toggle(OpenModeOut | OpenModeBinary)
+	assert.Equal(t, "out", mode.String())
+
+	// the original value isn't changed
+	assert.Equal(t, "0", modes.String())
+
+	assert.Equal(t, "out|bit7", mode.With(1<<7).String())
+	assert.Equal(t, "bit0|bit63", NewFlags[uint64]().With(1|1<<63).String())
+}
@@ -0,0 +1,80 @@
+package bitset
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+type Unsigned interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Flags is a bit mask with names for its bits. It's a value type,
+// every change returns a new mask sharing the names.
+//
+//	modes := bitset.NewFlags[OpenMode]("in", "out", "append", "binary")
+//	mode := modes.With(OpenModeIn | OpenModeBinary)
+//	mode.Has(OpenModeOut) // false
+//	mode.String()         // "in|binary"
+type Flags[T Unsigned] struct {
+	value T
+	names []string
+}
+
+// NewFlags returns an empty mask, names[i] is the name of the bit 1<<i.
+func NewFlags[T Unsigned](names ...string) Flags[T] {
+	return Flags[T]{names: names}
+}
+
+func (f Flags[T]) Value() T {
+	return f.value
+}
+
+// With returns the mask with the flags set.
+func (f Flags[T]) With(flags T) Flags[T] {
+	f.value |= flags
+	return f
+}
+
+func (f Flags[T]) Without(flags T) Flags[T] {
+	f.value &^= flags
+	return f
+}
+
+func (f Flags[T]) Toggle(flags T) Flags[T] {
+	f.value ^= flags
+	return f
+}
+
+// Has reports whether all the flags are set.
+func (f Flags[T]) Has(flags T) bool {
+	return f.value&flags == flags
+}
+
+func (f Flags[T]) HasAny(flags T) bool {
+	return f.value&flags != 0
+}
+
+func (f Flags[T]) Count() int {
+	return bits.OnesCount64(uint64(f.value))
+}
+
+// String joins the names of the set bits with "|",
+// bits without a name are written as "bitN".
+func (f Flags[T]) String() string {
+	if f.value == 0 {
+		return "0"
+	}
+
+	var parts []string
+	for value := uint64(f.value); value != 0; value &= value - 1 {
+		index := bits.TrailingZeros64(value)
+		if index < len(f.names) && f.names[index] != "" {
+			parts = append(parts, f.names[index])
+		} else {
+			parts = append(parts, "bit"+strconv.Itoa(index))
+		}
+	}
+	return strings.Join(parts, "|")
+}
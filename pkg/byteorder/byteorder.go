@@ -0,0 +1,180 @@
+// Package byteorder converts numbers to and from byte slices in a given
+// byte order and detects the byte order of the host.
+package byteorder
+
+import (
+	"math"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Order is either BigEndian or LittleEndian.
+type Order struct {
+	little bool
+}
+
+var (
+	BigEndian    = Order{little: false}
+	LittleEndian = Order{little: true}
+	// Native is the byte order of the host.
+	Native = detect()
+)
+
+func detect() Order {
+	// the first byte in memory is the least significant one on little endian hosts
+	number := uint16(0x0001)
+	if *(*byte)(unsafe.Pointer(&number)) == 0x01 {
+		return LittleEndian
+	}
+	return BigEndian
+}
+
+func IsLittleEndian() bool {
+	return Native == LittleEndian
+}
+
+func IsBigEndian() bool {
+	return Native == BigEndian
+}
+
+func (o Order) String() string {
+	if o.little {
+		return "LittleEndian"
+	}
+	return "BigEndian"
+}
+
+// get reads size bytes as an unsigned number, size is at most 8.
+func (o Order) get(data []byte, size int) uint64 {
+	_ = data[size-1] // a single bounds check
+	var value uint64
+	for idx := 0; idx < size; idx++ {
+		shift := 8 * idx
+		if !o.little {
+			shift = 8 * (size - idx - 1)
+		}
+		value |= uint64(data[idx]) << shift
+	}
+	return value
+}
+
+func (o Order) put(data []byte, size int, value uint64) {
+	_ = data[size-1]
+	for idx := 0; idx < size; idx++ {
+		shift := 8 * idx
+		if !o.little {
+			shift = 8 * (size - idx - 1)
+		}
+		data[idx] = byte(value >> shift)
+	}
+}
+
+func (o Order) Uint16(data []byte) uint16 {
+	return uint16(o.get(data, 2))
+}
+
+func (o Order) Uint32(data []byte) uint32 {
+	return uint32(o.get(data, 4))
+}
+
+func (o Order) Uint64(data []byte) uint64 {
+	return o.get(data, 8)
+}
+
+func (o Order) Uint128(data []byte) Uint128 {
+	_ = data[15]
+	if o.little {
+		return Uint128{Hi: o.Uint64(data[8:]), Lo: o.Uint64(data)}
+	}
+	return Uint128{Hi: o.Uint64(data), Lo: o.Uint64(data[8:])}
+}
+
+func (o Order) PutUint16(data []byte, value uint16) {
+	o.put(data, 2, uint64(value))
+}
+
+func (o Order) PutUint32(data []byte, value uint32) {
+	o.put(data, 4, uint64(value))
+}
+
+func (o Order) PutUint64(data []byte, value uint64) {
+	o.put(data, 8, value)
+}
+
+func (o Order) PutUint128(data []byte, value Uint128) {
+	_ = data[15]
+	if o.little {
+		o.PutUint64(data, value.Lo)
+		o.PutUint64(data[8:], value.Hi)
+	} else {
+		o.PutUint64(data, value.Hi)
+		o.PutUint64(data[8:], value.Lo)
+	}
+}
+
+// Signed numbers are stored in two's complement.
+
+func (o Order) Int16(data []byte) int16 {
+	return int16(o.Uint16(data))
+}
+
+func (o Order) Int32(data []byte) int32 {
+	return int32(o.Uint32(data))
+}
+
+func (o Order) Int64(data []byte) int64 {
+	return int64(o.Uint64(data))
+}
+
+func (o Order) PutInt16(data []byte, value int16) {
+	o.PutUint16(data, uint16(value))
+}
+
+func (o Order) PutInt32(data []byte, value int32) {
+	o.PutUint32(data, uint32(value))
+}
+
+func (o Order) PutInt64(data []byte, value int64) {
+	o.PutUint64(data, uint64(value))
+}
+
+// Floats are stored as their IEEE 754 bits.
+
+func (o Order) Float32(data []byte) float32 {
+	return math.Float32frombits(o.Uint32(data))
+}
+
+func (o Order) Float64(data []byte) float64 {
+	return math.Float64frombits(o.Uint64(data))
+}
+
+func (o Order) PutFloat32(data []byte, value float32) {
+	o.PutUint32(data, math.Float32bits(value))
+}
+
+func (o Order) PutFloat64(data []byte, value float64) {
+	o.PutUint64(data, math.Float64bits(value))
+}
+
+// Swap reverses the bytes of the number, e.g. 0x0102 becomes 0x0201.
+func Swap[T constraints.Integer](number T) T {
+	var out T
+	size := int(unsafe.Sizeof(out))
+	for idx := 0; idx < size; idx++ {
+		digit := byte(number >> (8 * idx))
+		out |= T(digit) << (8 * (size - idx - 1))
+	}
+	return out
+}
+
+// Uint128 is an unsigned 128-bit number.
+type Uint128 struct {
+	Hi uint64
+	Lo uint64
+}
+
+// Swap reverses the bytes of the number.
+func (u Uint128) Swap() Uint128 {
+	return Uint128{Hi: Swap(u.Lo), Lo: Swap(u.Hi)}
+}
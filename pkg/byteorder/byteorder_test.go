@@ -0,0 +1,214 @@
+package byteorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNative(t *testing.T) {
+	data := make([]byte, 8)
+	binary.NativeEndian.PutUint64(data, 0x0102030405060708)
+
+	assert.Equal(t, uint64(0x0102030405060708), Native.Uint64(data))
+	assert.NotEqual(t, IsLittleEndian(), IsBigEndian())
+	assert.Equal(t, data[0] == 0x08, IsLittleEndian())
+}
+
+func TestOrderMatchesEncodingBinary(t *testing.T) {
+	orders := map[Order]binary.ByteOrder{
+		BigEndian:    binary.BigEndian,
+		LittleEndian: binary.LittleEndian,
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	for order, expected := range orders {
+		t.Run(order.String(), func(t *testing.T) {
+			for range 1000 {
+				value := random.Uint64()
+				actual, reference := make([]byte, 8), make([]byte, 8)
+
+				order.PutUint16(actual, uint16(value))
+				expected.PutUint16(reference, uint16(value))
+				require.Equal(t, reference[:2], actual[:2])
+				require.Equal(t, uint16(value), order.Uint16(reference))
+
+				order.PutUint32(actual, uint32(value))
+				expected.PutUint32(reference, uint32(value))
+				require.Equal(t, reference[:4], actual[:4])
+				require.Equal(t, uint32(value), order.Uint32(reference))
+
+				order.PutUint64(actual, value)
+				expected.PutUint64(reference, value)
+				require.Equal(t, reference, actual)
+				require.Equal(t, value, order.Uint64(reference))
+			}
+		})
+	}
+}
+
+func TestUint128(t *testing.T) {
+	value := Uint128{Hi: 0x0001020304050607, Lo: 0x08090a0b0c0d0e0f}
+	data := make([]byte, 16)
+
+	BigEndian.PutUint128(data, value)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, data)
+	assert.Equal(t, value, BigEndian.Uint128(data))
+
+	LittleEndian.PutUint128(data, value)
+	assert.Equal(t, []byte{15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, data)
+	assert.Equal(t, value, LittleEndian.Uint128(data))
+
+	assert.Equal(t, Uint128{Hi: 0x0f0e0d0c0b0a0908, Lo: 0x0706050403020100}, value.Swap())
+	assert.Panics(t, func() { BigEndian.Uint128(data[:15]) })
+}
+
+func TestSignedAndFloats(t *testing.T) {
+	data := make([]byte, 8)
+
+	BigEndian.PutInt16(data, -2)
+	assert.Equal(t, []byte{0xff, 0xfe}, data[:2])
+	assert.Equal(t, int16(-2), BigEndian.Int16(data))
+
+	LittleEndian.PutInt32(data, math.MinInt32)
+	assert.Equal(t, []byte{0, 0, 0, 0x80}, data[:4])
+	assert.Equal(t, int32(math.MinInt32), LittleEndian.Int32(data))
+
+	BigEndian.PutInt64(data, -1)
+	assert.Equal(t, int64(-1), BigEndian.Int64(data))
+
+	BigEndian.PutFloat32(data, 1.5)
+	assert.Equal(t, []byte{0x3f, 0xc0, 0, 0}, data[:4])
+	assert.Equal(t, float32(1.5), BigEndian.Float32(data))
+
+	LittleEndian.PutFloat64(data, math.Inf(-1))
+	assert.Equal(t, math.Inf(-1), LittleEndian.Float64(data))
+
+	LittleEndian.PutFloat64(data, math.NaN())
+	assert.True(t, math.IsNaN(LittleEndian.Float64(data)))
+}
+
+func TestSwap(t *testing.T) {
+	assert.Equal(t, uint16(0x0201), Swap(uint16(0x0102)))
+	assert.Equal(t, uint32(0x04030201), Swap(uint32(0x01020304)))
+	assert.Equal(t, uint64(0x0807060504030201), Swap(uint64(0x0102030405060708)))
+	assert.Equal(t, uint8(0x12), Swap(uint8(0x12)))
+	assert.Equal(t, int16(-257), Swap(int16(-2)))
+	assert.Equal(t, int32(0x00000080), Swap(int32(math.MinInt32)))
+
+	type Port uint16
+	assert.Equal(t, Port(0x5000), Swap(Port(80)))
+
+	for _, value := range []uint64{0, 1, math.MaxUint64, 0x00FF00FF00FF00FF} {
+		assert.Equal(t, value, Swap(Swap(value)))
+	}
+}
+
+type header struct {
+	Magic    [4]byte
+	Version  uint16
+	Flags    uint16
+	Length   int32
+	_        [2]byte
+	Offset   int64
+	Ratio    float32
+	Scale    float64
+	Enabled  bool
+	Checksum [2]uint16
+	Delta    int8
+}
+
+type packet struct {
+	Header header
+	ID     Uint128
+}
+
+func TestEncodeMatchesEncodingBinary(t *testing.T) {
+	value := header{
+		Magic:    [4]byte{'G', 'O', 'P', 'H'},
+		Version:  2,
+		Flags:    0x8001,
+		Length:   -12,
+		Offset:   1 << 40,
+		Ratio:    0.5,
+		Scale:    -3.25,
+		Enabled:  true,
+		Checksum: [2]uint16{0xbeef, 0xcafe},
+		Delta:    -1,
+	}
+
+	orders := map[Order]binary.ByteOrder{
+		BigEndian:    binary.BigEndian,
+		LittleEndian: binary.LittleEndian,
+	}
+
+	for order, expected := range orders {
+		t.Run(order.String(), func(t *testing.T) {
+			var reference bytes.Buffer
+			require.NoError(t, binary.Write(&reference, expected, value))
+
+			data, err := Encode(order, value)
+			require.NoError(t, err)
+			assert.Equal(t, reference.Bytes(), data)
+
+			size, err := Size(&value)
+			require.NoError(t, err)
+			assert.Equal(t, binary.Size(value), size)
+
+			var decoded header
+			require.NoError(t, Decode(order, data, &decoded))
+			assert.Equal(t, value, decoded)
+		})
+	}
+}
+
+func TestEncodeUint128(t *testing.T) {
+	value := packet{ID: Uint128{Hi: 1, Lo: 2}}
+	value.Header.Version = 1
+
+	data, err := Encode(BigEndian, &value)
+	require.NoError(t, err)
+	require.Len(t, data, 56)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}, data[40:])
+
+	var decoded packet
+	require.NoError(t, Decode(BigEndian, data, &decoded))
+	assert.Equal(t, value, decoded)
+
+	data, err = Encode(LittleEndian, value.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, data)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := map[string]any{
+		"int":        int(1),
+		"string":     "text",
+		"slice":      []uint8{1},
+		"pointer":    struct{ Next *int }{},
+		"unexported": struct{ value uint8 }{},
+		"nil":        nil,
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Encode(BigEndian, value)
+			assert.ErrorIs(t, err, ErrNotFixedSize)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var value header
+	assert.ErrorIs(t, Decode(BigEndian, make([]byte, 10), &value), ErrShortBuffer)
+	assert.Error(t, Decode(BigEndian, make([]byte, 64), value))
+	assert.Error(t, Decode(BigEndian, make([]byte, 64), (*header)(nil)))
+
+	var number int
+	assert.ErrorIs(t, Decode(BigEndian, make([]byte, 8), &number), ErrNotFixedSize)
+}
@@ -0,0 +1,194 @@
+package byteorder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrNotFixedSize = errors.New("byteorder: type has no fixed size")
+	ErrShortBuffer  = errors.New("byteorder: buffer is too short")
+)
+
+var uint128Type = reflect.TypeFor[Uint128]()
+
+// Size returns the number of bytes Encode produces for the value.
+func Size(value any) (int, error) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return 0, fmt.Errorf("%w: %T", ErrNotFixedSize, value)
+	}
+	return sizeOf(v.Type())
+}
+
+// sizeOf supports booleans, numbers except int, uint and uintptr whose
+// size depends on the platform, and arrays and structs of them.
+func sizeOf(typ reflect.Type) (int, error) {
+	if typ == uint128Type {
+		return 16, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, nil
+	case reflect.Int16, reflect.Uint16:
+		return 2, nil
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4, nil
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8, nil
+	case reflect.Array:
+		size, err := sizeOf(typ.Elem())
+		if err != nil {
+			return 0, err
+		}
+		return size * typ.Len(), nil
+	case reflect.Struct:
+		total := 0
+		for idx := range typ.NumField() {
+			field := typ.Field(idx)
+			if !field.IsExported() && field.Name != "_" {
+				return 0, fmt.Errorf("%w: unexported field %s.%s", ErrNotFixedSize, typ, field.Name)
+			}
+
+			size, err := sizeOf(field.Type)
+			if err != nil {
+				return 0, err
+			}
+			total += size
+		}
+		return total, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrNotFixedSize, typ)
+	}
+}
+
+// Encode writes a fixed-size value field by field without padding,
+// blank fields are written as zeros.
+func Encode(order Order, value any) ([]byte, error) {
+	size, err := Size(value)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	order.encode(data, reflect.Indirect(reflect.ValueOf(value)))
+	return data, nil
+}
+
+// Decode fills the value pointed to by pointer from the beginning
+// of data, blank fields are skipped.
+func Decode(order Order, data []byte, pointer any) error {
+	v := reflect.ValueOf(pointer)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("byteorder: Decode needs a non-nil pointer, got %T", pointer)
+	}
+
+	v = v.Elem()
+	size, err := sizeOf(v.Type())
+	if err != nil {
+		return err
+	}
+	if len(data) < size {
+		return fmt.Errorf("%w: %d bytes for %s of %d bytes", ErrShortBuffer, len(data), v.Type(), size)
+	}
+
+	order.decode(data, v)
+	return nil
+}
+
+// encode writes v to data and returns the rest of data.
+func (o Order) encode(data []byte, v reflect.Value) []byte {
+	if v.Type() == uint128Type {
+		o.PutUint128(data, Uint128{Hi: v.Field(0).Uint(), Lo: v.Field(1).Uint()})
+		return data[16:]
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		data[0] = 0
+		if v.Bool() {
+			data[0] = 1
+		}
+		return data[1:]
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := int(v.Type().Size())
+		o.put(data, size, uint64(v.Int()))
+		return data[size:]
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size := int(v.Type().Size())
+		o.put(data, size, v.Uint())
+		return data[size:]
+	case reflect.Float32:
+		o.PutFloat32(data, float32(v.Float()))
+		return data[4:]
+	case reflect.Float64:
+		o.PutFloat64(data, v.Float())
+		return data[8:]
+	case reflect.Array:
+		for idx := range v.Len() {
+			data = o.encode(data, v.Index(idx))
+		}
+		return data
+	case reflect.Struct:
+		for idx := range v.NumField() {
+			if v.Type().Field(idx).Name == "_" {
+				size, _ := sizeOf(v.Field(idx).Type())
+				clear(data[:size])
+				data = data[size:]
+				continue
+			}
+			data = o.encode(data, v.Field(idx))
+		}
+		return data
+	}
+
+	panic("byteorder: unsupported kind " + v.Kind().String())
+}
+
+func (o Order) decode(data []byte, v reflect.Value) []byte {
+	if v.Type() == uint128Type {
+		v.Set(reflect.ValueOf(o.Uint128(data)))
+		return data[16:]
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(data[0] != 0)
+		return data[1:]
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := int(v.Type().Size())
+		// shifting left and back extends the sign
+		shift := 64 - 8*size
+		v.SetInt(int64(o.get(data, size)<<shift) >> shift)
+		return data[size:]
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size := int(v.Type().Size())
+		v.SetUint(o.get(data, size))
+		return data[size:]
+	case reflect.Float32:
+		v.SetFloat(float64(o.Float32(data)))
+		return data[4:]
+	case reflect.Float64:
+		v.SetFloat(o.Float64(data))
+		return data[8:]
+	case reflect.Array:
+		for idx := range v.Len() {
+			data = o.decode(data, v.Index(idx))
+		}
+		return data
+	case reflect.Struct:
+		for idx := range v.NumField() {
+			if v.Type().Field(idx).Name == "_" {
+				size, _ := sizeOf(v.Field(idx).Type())
+				data = data[size:]
+				continue
+			}
+			data = o.decode(data, v.Field(idx))
+		}
+		return data
+	}
+
+	panic("byteorder: unsupported kind " + v.Kind().String())
+}
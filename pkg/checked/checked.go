@@ -0,0 +1,111 @@
+// Package checked implements integer arithmetic which reports overflow
+// instead of silently wrapping around, for any integer type.
+package checked
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+var (
+	ErrIntOverflow  = errors.New("integer overflow")
+	ErrDivideByZero = errors.New("integer divide by zero")
+)
+
+func isSigned[T constraints.Integer]() bool {
+	return ^T(0) < 0
+}
+
+func bitSize[T constraints.Integer]() uint {
+	var zero T
+	return uint(unsafe.Sizeof(zero)) * 8
+}
+
+func MinValue[T constraints.Integer]() T {
+	if !isSigned[T]() {
+		return 0
+	}
+	return T(1) << (bitSize[T]() - 1)
+}
+
+func MaxValue[T constraints.Integer]() T {
+	return ^MinValue[T]()
+}
+
+func Add[T constraints.Integer](lhs, rhs T) (T, error) {
+	result := lhs + rhs
+	if (rhs > 0 && result < lhs) || (rhs < 0 && result > lhs) {
+		return 0, ErrIntOverflow
+	}
+	return result, nil
+}
+
+func Sub[T constraints.Integer](lhs, rhs T) (T, error) {
+	result := lhs - rhs
+	if (rhs > 0 && result > lhs) || (rhs < 0 && result < lhs) {
+		return 0, ErrIntOverflow
+	}
+	return result, nil
+}
+
+func Mul[T constraints.Integer](lhs, rhs T) (T, error) {
+	if lhs == 0 || rhs == 0 {
+		return 0, nil
+	}
+
+	// MinValue / -1 overflows itself, so the check below can't be used,
+	// ^T(0) is -1 for signed types
+	if isSigned[T]() && ((lhs == ^T(0) && rhs == MinValue[T]()) || (rhs == ^T(0) && lhs == MinValue[T]())) {
+		return 0, ErrIntOverflow
+	}
+
+	result := lhs * rhs
+	if result/rhs != lhs {
+		return 0, ErrIntOverflow
+	}
+	return result, nil
+}
+
+func Div[T constraints.Integer](lhs, rhs T) (T, error) {
+	if rhs == 0 {
+		return 0, ErrDivideByZero
+	}
+	if isSigned[T]() && lhs == MinValue[T]() && rhs == ^T(0) {
+		return 0, ErrIntOverflow
+	}
+	return lhs / rhs, nil
+}
+
+// Neg fails for MinValue of signed types and for
+// every unsigned value except zero.
+func Neg[T constraints.Integer](value T) (T, error) {
+	if (isSigned[T]() && value == MinValue[T]()) || (!isSigned[T]() && value != 0) {
+		return 0, ErrIntOverflow
+	}
+	return -value, nil
+}
+
+func Abs[T constraints.Integer](value T) (T, error) {
+	if value >= 0 {
+		return value, nil
+	}
+	return Neg(value)
+}
+
+// Shl fails if any set bit is shifted out or the sign changes.
+func Shl[T constraints.Integer](value T, shift uint) (T, error) {
+	if value == 0 {
+		return 0, nil
+	}
+	if shift >= bitSize[T]() {
+		return 0, ErrIntOverflow
+	}
+
+	result := value << shift
+	if result>>shift != value {
+		return 0, ErrIntOverflow
+	}
+	return result, nil
+}
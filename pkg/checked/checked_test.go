@@ -0,0 +1,172 @@
+package checked
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/constraints"
+)
+
+// reference operations are computed in int, which
+// can hold every result for 8-bit operands exactly
+type binaryCase[T constraints.Integer] struct {
+	name       string
+	checked    func(T, T) (T, error)
+	saturating func(T, T) T
+	wrapping   func(T, T) T
+	reference  func(int, int) int
+}
+
+func binaryCases[T constraints.Integer]() []binaryCase[T] {
+	return []binaryCase[T]{
+		{"add", Add[T], SaturatingAdd[T], WrappingAdd[T], func(lhs, rhs int) int { return lhs + rhs }},
+		{"sub", Sub[T], SaturatingSub[T], WrappingSub[T], func(lhs, rhs int) int { return lhs - rhs }},
+		{"mul", Mul[T], SaturatingMul[T], WrappingMul[T], func(lhs, rhs int) int { return lhs * rhs }},
+		{"div", Div[T], SaturatingDiv[T], WrappingDiv[T], func(lhs, rhs int) int { return lhs / rhs }},
+	}
+}
+
+func values[T int8 | uint8]() []T {
+	var result []T
+	for value := int(MinValue[T]()); value <= int(MaxValue[T]()); value++ {
+		result = append(result, T(value))
+	}
+	return result
+}
+
+func inRange[T int8 | uint8](value int) bool {
+	return value >= int(MinValue[T]()) && value <= int(MaxValue[T]())
+}
+
+func clamp[T int8 | uint8](value int) T {
+	return T(min(max(value, int(MinValue[T]())), int(MaxValue[T]())))
+}
+
+func testBinaryExhaustive[T int8 | uint8](t *testing.T) {
+	for _, test := range binaryCases[T]() {
+		t.Run(test.name, func(t *testing.T) {
+			for _, lhs := range values[T]() {
+				for _, rhs := range values[T]() {
+					if test.name == "div" && rhs == 0 {
+						_, err := test.checked(lhs, rhs)
+						require.ErrorIs(t, err, ErrDivideByZero)
+						require.Panics(t, func() { test.saturating(lhs, rhs) })
+						continue
+					}
+
+					expected := test.reference(int(lhs), int(rhs))
+					result, err := test.checked(lhs, rhs)
+					if inRange[T](expected) {
+						require.NoError(t, err, "%d %s %d", lhs, test.name, rhs)
+						require.Equal(t, T(expected), result, "%d %s %d", lhs, test.name, rhs)
+					} else {
+						require.ErrorIs(t, err, ErrIntOverflow, "%d %s %d", lhs, test.name, rhs)
+					}
+
+					require.Equal(t, clamp[T](expected), test.saturating(lhs, rhs), "%d %s %d", lhs, test.name, rhs)
+					require.Equal(t, T(expected), test.wrapping(lhs, rhs), "%d %s %d", lhs, test.name, rhs)
+				}
+			}
+		})
+	}
+}
+
+func testUnaryExhaustive[T int8 | uint8](t *testing.T) {
+	for _, value := range values[T]() {
+		expected := -int(value)
+		result, err := Neg(value)
+		if inRange[T](expected) {
+			require.NoError(t, err, "-%d", value)
+			require.Equal(t, T(expected), result)
+		} else {
+			require.ErrorIs(t, err, ErrIntOverflow, "-%d", value)
+		}
+		require.Equal(t, clamp[T](expected), SaturatingNeg(value))
+		require.Equal(t, T(expected), WrappingNeg(value))
+
+		expected = int(value)
+		if expected < 0 {
+			expected = -expected
+		}
+		result, err = Abs(value)
+		if inRange[T](expected) {
+			require.NoError(t, err, "|%d|", value)
+			require.Equal(t, T(expected), result)
+		} else {
+			require.ErrorIs(t, err, ErrIntOverflow, "|%d|", value)
+		}
+		require.Equal(t, clamp[T](expected), SaturatingAbs(value))
+		require.Equal(t, T(expected), WrappingAbs(value))
+
+		for shift := uint(0); shift <= 10; shift++ {
+			expected = int(value) << shift
+			result, err = Shl(value, shift)
+			if inRange[T](expected) {
+				require.NoError(t, err, "%d << %d", value, shift)
+				require.Equal(t, T(expected), result)
+			} else {
+				require.ErrorIs(t, err, ErrIntOverflow, "%d << %d", value, shift)
+			}
+			require.Equal(t, clamp[T](expected), SaturatingShl(value, shift))
+			require.Equal(t, T(expected), WrappingShl(value, shift))
+		}
+	}
+}
+
+func TestExhaustiveInt8(t *testing.T) {
+	testBinaryExhaustive[int8](t)
+	testUnaryExhaustive[int8](t)
+}
+
+func TestExhaustiveUint8(t *testing.T) {
+	testBinaryExhaustive[uint8](t)
+	testUnaryExhaustive[uint8](t)
+}
+
+func TestBounds(t *testing.T) {
+	assert.Equal(t, int8(math.MinInt8), MinValue[int8]())
+	assert.Equal(t, int8(math.MaxInt8), MaxValue[int8]())
+	assert.Equal(t, int64(math.MinInt64), MinValue[int64]())
+	assert.Equal(t, int64(math.MaxInt64), MaxValue[int64]())
+	assert.Equal(t, uint64(0), MinValue[uint64]())
+	assert.Equal(t, uint64(math.MaxUint64), MaxValue[uint64]())
+	assert.Equal(t, uintptr(math.MaxUint64), MaxValue[uintptr]())
+
+	type Celsius int16
+	assert.Equal(t, Celsius(math.MaxInt16), MaxValue[Celsius]())
+}
+
+func TestInt64Edges(t *testing.T) {
+	_, err := Mul(math.MinInt64, -1)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	_, err = Mul(-1, math.MinInt64)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	_, err = Mul(math.MaxInt64/2+1, -2)
+	assert.NoError(t, err)
+	_, err = Mul(math.MaxInt64/2+1, 2)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+
+	// the lesson version fails this one because of a negative divisor
+	result, err := Mul(-3, -3)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, result)
+
+	_, err = Div(math.MinInt64, -1)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	assert.Equal(t, int64(math.MaxInt64), SaturatingDiv(int64(math.MinInt64), -1))
+	assert.Equal(t, int64(math.MinInt64), WrappingDiv(int64(math.MinInt64), -1))
+
+	_, err = Add(uint64(math.MaxUint64), 1)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	assert.Equal(t, uint64(0), SaturatingSub(uint64(1), 2))
+
+	_, err = Shl(1, 63)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	result, err = Shl(-1, 63)
+	assert.NoError(t, err)
+	assert.Equal(t, math.MinInt, result)
+	_, err = Shl(uint64(1), 64)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+}
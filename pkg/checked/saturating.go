@@ -0,0 +1,72 @@
+package checked
+
+import "golang.org/x/exp/constraints"
+
+// Saturating functions clamp the result to the range of T on overflow.
+
+func SaturatingAdd[T constraints.Integer](lhs, rhs T) T {
+	result, err := Add(lhs, rhs)
+	if err != nil {
+		return bound[T](rhs > 0)
+	}
+	return result
+}
+
+func SaturatingSub[T constraints.Integer](lhs, rhs T) T {
+	result, err := Sub(lhs, rhs)
+	if err != nil {
+		return bound[T](rhs < 0)
+	}
+	return result
+}
+
+func SaturatingMul[T constraints.Integer](lhs, rhs T) T {
+	result, err := Mul(lhs, rhs)
+	if err != nil {
+		return bound[T]((lhs < 0) == (rhs < 0))
+	}
+	return result
+}
+
+// SaturatingDiv panics on division by zero like the / operator.
+func SaturatingDiv[T constraints.Integer](lhs, rhs T) T {
+	result, err := Div(lhs, rhs)
+	switch err {
+	case ErrDivideByZero:
+		panic(err)
+	case ErrIntOverflow:
+		return MaxValue[T]()
+	}
+	return result
+}
+
+func SaturatingNeg[T constraints.Integer](value T) T {
+	result, err := Neg(value)
+	if err != nil {
+		return bound[T](value < 0)
+	}
+	return result
+}
+
+func SaturatingAbs[T constraints.Integer](value T) T {
+	result, err := Abs(value)
+	if err != nil {
+		return MaxValue[T]()
+	}
+	return result
+}
+
+func SaturatingShl[T constraints.Integer](value T, shift uint) T {
+	result, err := Shl(value, shift)
+	if err != nil {
+		return bound[T](value > 0)
+	}
+	return result
+}
+
+func bound[T constraints.Integer](upper bool) T {
+	if upper {
+		return MaxValue[T]()
+	}
+	return MinValue[T]()
+}
@@ -0,0 +1,41 @@
+package checked
+
+import "golang.org/x/exp/constraints"
+
+// Wrapping functions compute the result modulo 2^N the same way as the
+// Go operators do, they exist to make the intent explicit at call sites.
+
+func WrappingAdd[T constraints.Integer](lhs, rhs T) T {
+	return lhs + rhs
+}
+
+func WrappingSub[T constraints.Integer](lhs, rhs T) T {
+	return lhs - rhs
+}
+
+func WrappingMul[T constraints.Integer](lhs, rhs T) T {
+	return lhs * rhs
+}
+
+// WrappingDiv returns MinValue for MinValue / -1
+// and panics on division by zero like the / operator.
+func WrappingDiv[T constraints.Integer](lhs, rhs T) T {
+	return lhs / rhs
+}
+
+func WrappingNeg[T constraints.Integer](value T) T {
+	return -value
+}
+
+// WrappingAbs returns MinValue for MinValue.
+func WrappingAbs[T constraints.Integer](value T) T {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// WrappingShl drops the bits shifted out.
+func WrappingShl[T constraints.Integer](value T, shift uint) T {
+	return value << shift
+}
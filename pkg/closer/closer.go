@@ -0,0 +1,140 @@
+package closer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang_course/pkg/panics"
+)
+
+var ErrClosed = errors.New("closer: already closed")
+
+type Action func(ctx context.Context) error
+
+// Closer releases resources in the reverse order of registration.
+// Actions added together with AddGroup run in parallel as one step.
+// The zero value is ready to use.
+type Closer struct {
+	// Timeout limits the whole Close call, zero means no limit
+	// besides the deadline of the context passed to Close.
+	Timeout time.Duration
+
+	mutex  sync.Mutex
+	groups [][]Action
+	closed bool
+	done   chan struct{}
+	err    error
+}
+
+// Add registers the action. After Close has been called the action
+// isn't registered and ErrClosed is returned, so a resource acquired
+// during shutdown can be released by the caller right away.
+func (c *Closer) Add(action Action) error {
+	return c.AddGroup(action)
+}
+
+// AddGroup registers actions to run in parallel, see Add.
+func (c *Closer) AddGroup(actions ...Action) error {
+	group := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		if action != nil {
+			group = append(group, action)
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	if len(group) != 0 {
+		c.groups = append(c.groups, group)
+	}
+	return nil
+}
+
+// Close runs all actions and returns their errors joined together.
+// Actions that don't return before the deadline are reported with
+// the context error and left running. Further calls wait for the
+// first one and return the same error.
+func (c *Closer) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	if c.closed {
+		done := c.done
+		c.mutex.Unlock()
+
+		<-done
+		return c.err
+	}
+
+	c.closed = true
+	c.done = make(chan struct{})
+	groups := c.groups
+	c.groups = nil
+	c.mutex.Unlock()
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for idx := len(groups) - 1; idx >= 0; idx-- {
+		errs = append(errs, runGroup(ctx, groups[idx])...)
+	}
+
+	c.err = errors.Join(errs...)
+	close(c.done)
+	return c.err
+}
+
+func runGroup(ctx context.Context, group []Action) []error {
+	if len(group) == 1 {
+		return []error{runAction(ctx, group[0])}
+	}
+
+	errs := make([]error, len(group))
+	wg := sync.WaitGroup{}
+	wg.Add(len(group))
+	for idx, action := range group {
+		go func() {
+			defer wg.Done()
+			errs[idx] = runAction(ctx, action)
+		}()
+	}
+
+	wg.Wait()
+	return errs
+}
+
+func runAction(ctx context.Context, action Action) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- safeRun(ctx, action)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("closer: action didn't finish: %w", ctx.Err())
+	}
+}
+
+func safeRun(ctx context.Context, action Action) error {
+	err := panics.SafeCall(func() error {
+		return action(ctx)
+	})
+
+	var panicErr *panics.PanicError
+	if errors.As(err, &panicErr) {
+		return fmt.Errorf("closer: action %w", panicErr)
+	}
+	return err
+}
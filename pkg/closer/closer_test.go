@@ -0,0 +1,157 @@
+package closer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloserLIFO(t *testing.T) {
+	var closer Closer
+	var order []string
+
+	for _, name := range []string{"connections", "database", "worker"} {
+		closer.Add(func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	closer.Add(nil)
+
+	assert.NoError(t, closer.Close(context.Background()))
+	assert.Equal(t, []string{"worker", "database", "connections"}, order)
+}
+
+func TestCloserCollectsErrors(t *testing.T) {
+	var closer Closer
+	err1 := errors.New("error 1")
+	err2 := errors.New("error 2")
+
+	var called atomic.Int32
+	closer.Add(func(ctx context.Context) error {
+		called.Add(1)
+		return err1
+	})
+	closer.Add(func(ctx context.Context) error {
+		called.Add(1)
+		panic("boom")
+	})
+	closer.Add(func(ctx context.Context) error {
+		called.Add(1)
+		return err2
+	})
+
+	err := closer.Close(context.Background())
+	assert.Equal(t, int32(3), called.Load())
+	assert.ErrorIs(t, err, err1)
+	assert.ErrorIs(t, err, err2)
+	assert.ErrorContains(t, err, "action panicked: boom")
+}
+
+func TestCloserGroupRunsInParallel(t *testing.T) {
+	var closer Closer
+	var order []string
+	var mutex sync.Mutex
+
+	record := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		order = append(order, name)
+	}
+
+	closer.Add(func(ctx context.Context) error {
+		record("last")
+		return nil
+	})
+
+	barrier := sync.WaitGroup{}
+	barrier.Add(3)
+	group := make([]Action, 3)
+	for idx := range group {
+		group[idx] = func(ctx context.Context) error {
+			// deadlocks unless all actions of the group run together
+			barrier.Done()
+			barrier.Wait()
+			record("group")
+			return nil
+		}
+	}
+	closer.AddGroup(group...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, closer.Close(ctx))
+	assert.Equal(t, []string{"group", "group", "group", "last"}, order)
+}
+
+func TestCloserTimeout(t *testing.T) {
+	closer := Closer{Timeout: 50 * time.Millisecond}
+
+	var lastCalled atomic.Bool
+	closer.Add(func(ctx context.Context) error {
+		lastCalled.Store(true)
+		return ctx.Err()
+	})
+	closer.Add(func(ctx context.Context) error {
+		// doesn't respect ctx
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	err := closer.Close(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "action didn't finish")
+
+	// actions after the deadline are still called with the expired context
+	assert.Eventually(t, lastCalled.Load, time.Second, time.Millisecond)
+}
+
+func TestCloserCloseTwice(t *testing.T) {
+	var closer Closer
+	failure := errors.New("failure")
+
+	var calls atomic.Int32
+	closer.Add(func(ctx context.Context) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return failure
+	})
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			errs <- closer.Close(context.Background())
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, <-errs, failure)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+}
+
+func TestCloserAddAfterClose(t *testing.T) {
+	var closer Closer
+	require.NoError(t, closer.Close(context.Background()))
+
+	var called atomic.Bool
+	err := closer.Add(func(ctx context.Context) error {
+		called.Store(true)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, closer.AddGroup(nil), ErrClosed)
+
+	require.NoError(t, closer.Close(context.Background()))
+	assert.False(t, called.Load())
+}
@@ -0,0 +1,195 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// MaxBlockLen limits the number of values in a block, so corrupt
+// data can't make the decoder allocate an arbitrary amount of memory.
+const MaxBlockLen = 1 << 16
+
+var (
+	ErrBlockTooLarge = fmt.Errorf("codec: block has more than %d values", MaxBlockLen)
+	ErrNotSorted     = errors.New("codec: values are not sorted")
+)
+
+// AppendBlock uses frame of reference: the minimum is stored once and the
+// differences to it are packed with the bit width of the largest one.
+//
+// The layout is uvarint count, uvarint minimum, a byte with the width
+// and count*width bits, the first value in the lowest bits.
+func AppendBlock(dst []byte, values []uint32) ([]byte, error) {
+	if len(values) > MaxBlockLen {
+		return dst, ErrBlockTooLarge
+	}
+
+	reference, width := frame(values)
+	dst = AppendUvarint(dst, uint64(len(values)))
+	dst = AppendUvarint(dst, uint64(reference))
+	dst = append(dst, byte(width))
+	return pack(dst, values, reference, width), nil
+}
+
+// DecodeBlock appends the values of the block at the start of data to dst
+// and returns the extended dst and the number of bytes read.
+func DecodeBlock(dst []uint32, data []byte) ([]uint32, int, error) {
+	count, offset, err := Uvarint(data)
+	if err != nil {
+		return dst, 0, err
+	}
+
+	reference, size, err := Uvarint(data[offset:])
+	if err != nil {
+		return dst, 0, noEOF(err)
+	}
+	offset += size
+
+	if offset >= len(data) {
+		return dst, 0, io.ErrUnexpectedEOF
+	}
+	width := int(data[offset])
+	offset++
+
+	if err := validate(count, reference, width); err != nil {
+		return dst, 0, err
+	}
+
+	size = packedLen(int(count), width)
+	if len(data)-offset < size {
+		return dst, 0, io.ErrUnexpectedEOF
+	}
+
+	dst, err = unpack(dst, data[offset:offset+size], int(count), uint32(reference), width)
+	return dst, offset + size, err
+}
+
+// AppendDeltas encodes a non-decreasing sequence as a block of differences
+// between neighbours, which are small for dense sequences like posting lists.
+func AppendDeltas(dst []byte, values []uint32) ([]byte, error) {
+	deltas, err := toDeltas(values)
+	if err != nil {
+		return dst, err
+	}
+	return AppendBlock(dst, deltas)
+}
+
+func DecodeDeltas(dst []uint32, data []byte) ([]uint32, int, error) {
+	start := len(dst)
+	dst, size, err := DecodeBlock(dst, data)
+	if err != nil {
+		return dst, size, err
+	}
+
+	return dst, size, fromDeltas(dst[start:])
+}
+
+// BitWidth is the number of bits needed to store the value.
+func BitWidth(value uint32) int {
+	return bits.Len32(value)
+}
+
+func frame(values []uint32) (uint32, int) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	minimum, maximum := values[0], values[0]
+	for _, value := range values[1:] {
+		minimum = min(minimum, value)
+		maximum = max(maximum, value)
+	}
+	return minimum, BitWidth(maximum - minimum)
+}
+
+func packedLen(count, width int) int {
+	return (count*width + 7) / 8
+}
+
+func pack(dst []byte, values []uint32, reference uint32, width int) []byte {
+	var buffer uint64
+	var buffered int
+	for _, value := range values {
+		buffer |= uint64(value-reference) << buffered
+		buffered += width
+		for buffered >= 8 {
+			dst = append(dst, byte(buffer))
+			buffer >>= 8
+			buffered -= 8
+		}
+	}
+
+	if buffered > 0 {
+		dst = append(dst, byte(buffer))
+	}
+	return dst
+}
+
+func unpack(dst []uint32, data []byte, count int, reference uint32, width int) ([]uint32, error) {
+	mask := uint64(1)<<width - 1
+	var buffer uint64
+	var buffered, position int
+	for range count {
+		for buffered < width {
+			buffer |= uint64(data[position]) << buffered
+			position++
+			buffered += 8
+		}
+
+		delta := buffer & mask
+		if delta > math.MaxUint32-uint64(reference) {
+			return dst, ErrCorrupt
+		}
+
+		dst = append(dst, reference+uint32(delta))
+		buffer >>= width
+		buffered -= width
+	}
+	return dst, nil
+}
+
+func validate(count, reference uint64, width int) error {
+	switch {
+	case count > MaxBlockLen:
+		return ErrBlockTooLarge
+	case reference > math.MaxUint32, width > 32:
+		return ErrCorrupt
+	}
+	return nil
+}
+
+func toDeltas(values []uint32) ([]uint32, error) {
+	deltas := make([]uint32, len(values))
+	previous := uint32(0)
+	for idx, value := range values {
+		if value < previous {
+			return nil, ErrNotSorted
+		}
+		deltas[idx] = value - previous
+		previous = value
+	}
+	return deltas, nil
+}
+
+func fromDeltas(values []uint32) error {
+	previous := uint32(0)
+	for idx, delta := range values {
+		if delta > math.MaxUint32-previous {
+			return ErrCorrupt
+		}
+		previous += delta
+		values[idx] = previous
+	}
+	return nil
+}
+
+// noEOF is used when the data ends after a part of a value was read.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
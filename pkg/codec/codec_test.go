@@ -0,0 +1,375 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZigZag(t *testing.T) {
+	tests := map[int64]uint64{
+		0:             0,
+		-1:            1,
+		1:             2,
+		-2:            3,
+		2:             4,
+		math.MaxInt64: math.MaxUint64 - 1,
+		math.MinInt64: math.MaxUint64,
+	}
+
+	for value, expected := range tests {
+		assert.Equal(t, expected, ZigZag(value), value)
+		assert.Equal(t, value, UnZigZag(expected), value)
+	}
+}
+
+func TestUvarintMatchesEncodingBinary(t *testing.T) {
+	values := []uint64{0, 1, 127, 128, 255, 300, 16383, 16384, math.MaxUint32, math.MaxUint64}
+	random := rand.New(rand.NewPCG(1, 2))
+	for range 1000 {
+		values = append(values, random.Uint64()>>random.IntN(64))
+	}
+
+	for _, value := range values {
+		data := AppendUvarint(nil, value)
+		assert.Equal(t, binary.AppendUvarint(nil, value), data)
+		assert.Equal(t, len(data), UvarintLen(value))
+
+		decoded, size, err := Uvarint(data)
+		require.NoError(t, err)
+		assert.Equal(t, value, decoded)
+		assert.Equal(t, len(data), size)
+	}
+}
+
+func TestVarint(t *testing.T) {
+	for _, value := range []int64{0, -1, 1, -64, 64, math.MinInt64, math.MaxInt64} {
+		data := AppendVarint(nil, value)
+		assert.Equal(t, binary.AppendVarint(nil, value), data)
+
+		decoded, size, err := Varint(data)
+		require.NoError(t, err)
+		assert.Equal(t, value, decoded)
+		assert.Equal(t, len(data), size)
+	}
+
+	// small negative numbers take a single byte
+	assert.Len(t, AppendVarint(nil, -63), 1)
+}
+
+func TestUvarintErrors(t *testing.T) {
+	_, _, err := Uvarint(nil)
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, _, err = Uvarint([]byte{0x80, 0x80})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, _, err = Uvarint([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, _, err = Uvarint([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x81, 0x00})
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestBlock(t *testing.T) {
+	tests := map[string]struct {
+		values []uint32
+		width  int
+	}{
+		"empty":     {values: nil, width: 0},
+		"constant":  {values: []uint32{7, 7, 7, 7}, width: 0},
+		"small":     {values: []uint32{1000, 1001, 1003, 1007}, width: 3},
+		"full":      {values: []uint32{0, math.MaxUint32}, width: 32},
+		"single":    {values: []uint32{42}, width: 0},
+		"unaligned": {values: []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, width: 4},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := AppendBlock([]byte{0xAA}, test.values)
+			require.NoError(t, err)
+			assert.Equal(t, byte(0xAA), data[0])
+
+			_, width := frame(test.values)
+			assert.Equal(t, test.width, width)
+
+			// appends to dst and ignores the trailing bytes
+			dst := []uint32{99}
+			dst, size, err := DecodeBlock(dst, append(data[1:], 0xFF))
+			require.NoError(t, err)
+			assert.Equal(t, len(data)-1, size)
+			assert.Equal(t, append([]uint32{99}, test.values...), dst)
+		})
+	}
+}
+
+func TestBlockSize(t *testing.T) {
+	values := make([]uint32, 128)
+	for idx := range values {
+		values[idx] = 1_000_000 + uint32(idx%16)
+	}
+
+	data, err := AppendBlock(nil, values)
+	require.NoError(t, err)
+
+	// 2 bytes of count, 3 of reference, 1 of width and 128 values * 4 bits
+	assert.Len(t, data, 2+3+1+64)
+}
+
+func TestBlockErrors(t *testing.T) {
+	_, err := AppendBlock(nil, make([]uint32, MaxBlockLen+1))
+	assert.ErrorIs(t, err, ErrBlockTooLarge)
+
+	data, err := AppendBlock(nil, []uint32{1, 100, 10000})
+	require.NoError(t, err)
+
+	for size := 1; size < len(data); size++ {
+		_, _, err := DecodeBlock(nil, data[:size])
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, size)
+	}
+
+	_, _, err = DecodeBlock(nil, nil)
+	assert.ErrorIs(t, err, io.EOF)
+
+	tests := map[string][]byte{
+		"count":     append(AppendUvarint(nil, MaxBlockLen+1), 0, 0),
+		"reference": append(AppendUvarint([]byte{1}, math.MaxUint32+1), 0),
+		"width":     {1, 0, 33, 0, 0, 0, 0, 0},
+		"overflow":  {1, 0xff, 0xff, 0xff, 0xff, 0x0f, 1, 1},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := DecodeBlock(nil, data)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, io.ErrUnexpectedEOF)
+		})
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	values := []uint32{3, 5, 5, 10, 1000, 1001, 1002, math.MaxUint32}
+	data, err := AppendDeltas(nil, values)
+	require.NoError(t, err)
+
+	decoded, size, err := DecodeDeltas(nil, data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), size)
+	assert.Equal(t, values, decoded)
+
+	_, err = AppendDeltas(nil, []uint32{1, 3, 2})
+	assert.ErrorIs(t, err, ErrNotSorted)
+
+	// deltas summing above MaxUint32
+	data, err = AppendBlock(nil, []uint32{math.MaxUint32, 1})
+	require.NoError(t, err)
+	_, _, err = DecodeDeltas(nil, data)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestDeltasAreSmaller(t *testing.T) {
+	values := make([]uint32, 1024)
+	for idx := range values {
+		values[idx] = uint32(idx * 3)
+	}
+
+	plain, err := AppendBlock(nil, values)
+	require.NoError(t, err)
+	deltas, err := AppendDeltas(nil, values)
+	require.NoError(t, err)
+
+	assert.Less(t, len(deltas), len(plain)/5)
+}
+
+func TestStream(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+
+	require.NoError(t, writer.WriteUvarint(300))
+	require.NoError(t, writer.WriteVarint(-300))
+	require.NoError(t, writer.WriteBlock([]uint32{10, 20, 30}))
+	require.NoError(t, writer.WriteDeltas([]uint32{100, 101, 105}))
+	require.NoError(t, writer.WriteBlock(nil))
+	assert.ErrorIs(t, writer.WriteDeltas([]uint32{2, 1}), ErrNotSorted)
+
+	reader := NewReader(&buffer)
+
+	unsigned, err := reader.ReadUvarint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(300), unsigned)
+
+	signed, err := reader.ReadVarint()
+	require.NoError(t, err)
+	assert.Equal(t, int64(-300), signed)
+
+	values, err := reader.ReadBlock(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{10, 20, 30}, values)
+
+	values, err = reader.ReadDeltas(values[:0])
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{100, 101, 105}, values)
+
+	values, err = reader.ReadBlock(nil)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = reader.ReadUvarint()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestStreamTruncated(t *testing.T) {
+	data, err := AppendBlock(nil, []uint32{1, 100, 10000})
+	require.NoError(t, err)
+
+	for size := 1; size < len(data); size++ {
+		_, err := NewReader(bytes.NewReader(data[:size])).ReadBlock(nil)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, size)
+	}
+
+	_, err = NewReader(bytes.NewReader([]byte{0x80})).ReadVarint()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestStreamWriteError(t *testing.T) {
+	writer := NewWriter(failingWriter{})
+	assert.ErrorIs(t, writer.WriteUvarint(1), io.ErrClosedPipe)
+	assert.ErrorIs(t, writer.WriteBlock([]uint32{1}), io.ErrClosedPipe)
+}
+
+func FuzzUvarint(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{0x80, 0x01})
+	f.Add([]byte{0xaa, 0x00})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, size, err := Uvarint(data)
+		expected, expectedSize := binary.Uvarint(data)
+		if expectedSize > 0 {
+			require.NoError(t, err)
+			require.Equal(t, expected, value)
+			require.Equal(t, expectedSize, size)
+			// non-canonical encodings like 0x80 0x00 are accepted
+			canonical := AppendUvarint(nil, value)
+			require.LessOrEqual(t, len(canonical), size)
+		} else {
+			require.Error(t, err)
+		}
+
+		streamed, streamErr := NewReader(bytes.NewReader(data)).ReadUvarint()
+		if err == nil {
+			require.NoError(t, streamErr)
+			require.Equal(t, value, streamed)
+		} else {
+			require.Error(t, streamErr)
+		}
+	})
+}
+
+func FuzzBlock(f *testing.F) {
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// decoding arbitrary bytes must not panic
+		_, _, _ = DecodeBlock(nil, data)
+		_, _, _ = DecodeDeltas(nil, data)
+		_, _ = NewReader(bytes.NewReader(data)).ReadBlock(nil)
+
+		values := make([]uint32, len(data)/4)
+		for idx := range values {
+			values[idx] = binary.LittleEndian.Uint32(data[4*idx:])
+		}
+
+		encoded, err := AppendBlock(nil, values)
+		require.NoError(t, err)
+		decoded, size, err := DecodeBlock(nil, encoded)
+		require.NoError(t, err)
+		require.Equal(t, len(encoded), size)
+		require.True(t, slices.Equal(values, decoded))
+
+		slices.Sort(values)
+		encoded, err = AppendDeltas(nil, values)
+		require.NoError(t, err)
+		decoded, err = NewReader(bytes.NewReader(encoded)).ReadDeltas(nil)
+		require.NoError(t, err)
+		require.True(t, slices.Equal(values, decoded))
+	})
+}
+
+func benchmarkValues(count int, spread uint32) []uint32 {
+	random := rand.New(rand.NewPCG(1, 2))
+	values := make([]uint32, count)
+	for idx := range values {
+		values[idx] = 1_000_000 + random.Uint32N(spread)
+	}
+	return values
+}
+
+func BenchmarkAppendBlock(b *testing.B) {
+	values := benchmarkValues(128, 1<<12)
+	buffer := make([]byte, 0, 1024)
+	b.SetBytes(int64(4 * len(values)))
+	for range b.N {
+		buffer, _ = AppendBlock(buffer[:0], values)
+	}
+}
+
+func BenchmarkDecodeBlock(b *testing.B) {
+	data, _ := AppendBlock(nil, benchmarkValues(128, 1<<12))
+	values := make([]uint32, 0, 128)
+	b.SetBytes(4 * 128)
+	for range b.N {
+		values, _, _ = DecodeBlock(values[:0], data)
+	}
+}
+
+func BenchmarkDecodeDeltas(b *testing.B) {
+	values := benchmarkValues(128, 1<<20)
+	slices.Sort(values)
+	data, _ := AppendDeltas(nil, values)
+	b.SetBytes(4 * 128)
+	for range b.N {
+		values, _, _ = DecodeDeltas(values[:0], data)
+	}
+}
+
+func BenchmarkUvarint(b *testing.B) {
+	random := rand.New(rand.NewPCG(1, 2))
+	var data []byte
+	for range 1024 {
+		data = AppendUvarint(data, random.Uint64()>>random.IntN(64))
+	}
+
+	b.Run("codec", func(b *testing.B) {
+		for range b.N {
+			for rest := data; len(rest) > 0; {
+				_, size, _ := Uvarint(rest)
+				rest = rest[size:]
+			}
+		}
+	})
+
+	b.Run("encoding/binary", func(b *testing.B) {
+		for range b.N {
+			for rest := data; len(rest) > 0; {
+				_, size := binary.Uvarint(rest)
+				rest = rest[size:]
+			}
+		}
+	})
+}
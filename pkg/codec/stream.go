@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"bufio"
+	"io"
+)
+
+// Writer writes encoded numbers and blocks to an io.Writer.
+// It doesn't buffer, so wrap w in a bufio.Writer for small writes.
+type Writer struct {
+	w       io.Writer
+	scratch []byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) WriteUvarint(value uint64) error {
+	w.scratch = AppendUvarint(w.scratch[:0], value)
+	return w.flush()
+}
+
+func (w *Writer) WriteVarint(value int64) error {
+	w.scratch = AppendVarint(w.scratch[:0], value)
+	return w.flush()
+}
+
+func (w *Writer) WriteBlock(values []uint32) error {
+	var err error
+	if w.scratch, err = AppendBlock(w.scratch[:0], values); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func (w *Writer) WriteDeltas(values []uint32) error {
+	var err error
+	if w.scratch, err = AppendDeltas(w.scratch[:0], values); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	_, err := w.w.Write(w.scratch)
+	return err
+}
+
+// Reader reads what Writer wrote. Every method returns io.EOF
+// only if the stream ends exactly before the value.
+type Reader struct {
+	r       *bufio.Reader
+	scratch []byte
+}
+
+func NewReader(r io.Reader) *Reader {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+	return &Reader{r: buffered}
+}
+
+func (r *Reader) ReadUvarint() (uint64, error) {
+	var value uint64
+	for idx := 0; ; idx++ {
+		digit, err := r.r.ReadByte()
+		if err != nil {
+			if idx > 0 {
+				return 0, noEOF(err)
+			}
+			return 0, err
+		}
+
+		if idx == MaxVarintLen64-1 && digit > 1 {
+			return 0, ErrOverflow
+		}
+
+		value |= uint64(digit&0x7f) << (7 * idx)
+		if digit < 0x80 {
+			return value, nil
+		}
+	}
+}
+
+func (r *Reader) ReadVarint() (int64, error) {
+	value, err := r.ReadUvarint()
+	return UnZigZag(value), err
+}
+
+// ReadBlock appends the values of the next block to dst.
+func (r *Reader) ReadBlock(dst []uint32) ([]uint32, error) {
+	count, err := r.ReadUvarint()
+	if err != nil {
+		return dst, err
+	}
+
+	reference, err := r.ReadUvarint()
+	if err != nil {
+		return dst, noEOF(err)
+	}
+
+	width, err := r.r.ReadByte()
+	if err != nil {
+		return dst, noEOF(err)
+	}
+
+	if err := validate(count, reference, int(width)); err != nil {
+		return dst, err
+	}
+
+	size := packedLen(int(count), int(width))
+	if cap(r.scratch) < size {
+		r.scratch = make([]byte, size)
+	}
+	r.scratch = r.scratch[:size]
+
+	if _, err := io.ReadFull(r.r, r.scratch); err != nil {
+		return dst, noEOF(err)
+	}
+
+	return unpack(dst, r.scratch, int(count), uint32(reference), int(width))
+}
+
+func (r *Reader) ReadDeltas(dst []uint32) ([]uint32, error) {
+	start := len(dst)
+	dst, err := r.ReadBlock(dst)
+	if err != nil {
+		return dst, err
+	}
+	return dst, fromDeltas(dst[start:])
+}
@@ -0,0 +1,78 @@
+// Package codec implements compact encodings of integers: LEB128 varints,
+// zigzag for signed numbers and bit-packed blocks of uint32.
+package codec
+
+import (
+	"errors"
+	"io"
+)
+
+// MaxVarintLen64 is the longest encoding of a 64-bit number.
+const MaxVarintLen64 = 10
+
+var (
+	ErrOverflow = errors.New("codec: varint overflows 64 bits")
+	ErrCorrupt  = errors.New("codec: corrupt data")
+)
+
+// ZigZag maps signed numbers to unsigned ones so that numbers with a small
+// absolute value stay small: 0, -1, 1, -2, 2 become 0, 1, 2, 3, 4.
+func ZigZag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
+
+func UnZigZag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+// AppendUvarint writes 7 bits per byte starting from the least
+// significant ones, the high bit of a byte is set if more bytes follow.
+func AppendUvarint(dst []byte, value uint64) []byte {
+	for value >= 0x80 {
+		dst = append(dst, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(dst, byte(value))
+}
+
+// Uvarint returns the number and the count of bytes read. It fails with
+// io.ErrUnexpectedEOF if data ends in the middle of the number.
+func Uvarint(data []byte) (uint64, int, error) {
+	var value uint64
+	for idx := 0; idx < len(data); idx++ {
+		digit := data[idx]
+		// the 10th byte can hold only the last bit and can't be followed by more
+		if idx == MaxVarintLen64-1 && digit > 1 {
+			return 0, 0, ErrOverflow
+		}
+
+		value |= uint64(digit&0x7f) << (7 * idx)
+		if digit < 0x80 {
+			return value, idx + 1, nil
+		}
+	}
+
+	if len(data) == 0 {
+		return 0, 0, io.EOF
+	}
+	return 0, 0, io.ErrUnexpectedEOF
+}
+
+func AppendVarint(dst []byte, value int64) []byte {
+	return AppendUvarint(dst, ZigZag(value))
+}
+
+func Varint(data []byte) (int64, int, error) {
+	value, size, err := Uvarint(data)
+	return UnZigZag(value), size, err
+}
+
+// UvarintLen returns the number of bytes AppendUvarint writes.
+func UvarintLen(value uint64) int {
+	size := 1
+	for value >= 0x80 {
+		value >>= 7
+		size++
+	}
+	return size
+}
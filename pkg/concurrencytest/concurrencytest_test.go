@@ -0,0 +1,100 @@
+package concurrencytest
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang_course/pkg/atomics"
+)
+
+func TestStressCorrectIncrement(t *testing.T) {
+	config := Config{Goroutines: 8, Iterations: 500}
+
+	var counter atomic.Int64
+	Verify(t, config, func(y *Yielder, worker, iteration int) {
+		counter.Add(1)
+	}, Equal("counter", int64(8*500), counter.Load))
+}
+
+func TestConfigYieldProbability(t *testing.T) {
+	assert.Equal(t, 0.1, Config{}.withDefaults().YieldProbability)
+	assert.Equal(t, 0.5, Config{YieldProbability: 0.5}.withDefaults().YieldProbability)
+	assert.Equal(t, 0.0, Config{YieldProbability: NoYield}.withDefaults().YieldProbability)
+}
+
+func TestStressIncorrectIncrement(t *testing.T) {
+	config := Config{Goroutines: 8, Iterations: 500, YieldProbability: 1, Seed: 42}
+
+	// load and store are atomic on their own, but the increment isn't
+	var counter atomic.Int64
+	err := Stress(config, func(y *Yielder, worker, iteration int) {
+		value := counter.Load()
+		y.Yield()
+		counter.Store(value + 1)
+	}, Equal("counter", int64(8*500), counter.Load))
+
+	assert.ErrorContains(t, err, "counter: expected 4000")
+	assert.ErrorContains(t, err, "seed 42")
+}
+
+func TestStressReportsPanics(t *testing.T) {
+	config := Config{Goroutines: 2, Iterations: 1}
+
+	err := Stress(config, func(y *Yielder, worker, iteration int) {
+		panic("boom")
+	})
+
+	assert.ErrorContains(t, err, "panicked: boom")
+}
+
+func TestStressLongAdder(t *testing.T) {
+	config := Config{Goroutines: 16, Iterations: 1000}
+
+	var adder atomics.LongAdder
+	Verify(t, config, func(y *Yielder, worker, iteration int) {
+		adder.Increment()
+		y.Yield()
+		adder.Decrement()
+		adder.Increment()
+	}, Equal("sum", int64(16*1000), adder.Sum), func() error {
+		if adder.Sum() < 0 {
+			return errors.New("negative sum")
+		}
+		return nil
+	})
+}
+
+type unpaddedCounters struct {
+	values [8]atomic.Int64
+}
+
+type paddedCounters struct {
+	values [8]atomics.PaddedInt64
+}
+
+func TestCompareLayouts(t *testing.T) {
+	var unpadded unpaddedCounters
+	var padded paddedCounters
+
+	report := CompareLayouts(8, 10000,
+		Layout{Name: "unpadded", Worker: func(worker int) func() {
+			return func() { unpadded.values[worker].Add(1) }
+		}},
+		Layout{Name: "padded", Worker: func(worker int) func() {
+			return func() { padded.values[worker].Add(1) }
+		}},
+	)
+
+	assert.Equal(t, 8, report.Goroutines)
+	assert.Equal(t, 80000, report.Unpadded.Operations)
+	assert.Equal(t, 80000, report.Padded.Operations)
+	assert.Equal(t, int64(10000), unpadded.values[3].Load())
+	assert.Equal(t, int64(10000), padded.values[3].Load())
+	assert.Greater(t, report.Speedup, 0.0)
+	assert.Contains(t, report.String(), "speedup:")
+
+	t.Log("\n" + report.String())
+}
@@ -0,0 +1,96 @@
+package concurrencytest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layout describes one memory layout of the type under test.
+// Worker is called once per goroutine and returns the operation
+// that goroutine repeats, usually an update of its own field.
+type Layout struct {
+	Name   string
+	Worker func(worker int) func()
+}
+
+type LayoutResult struct {
+	Name         string
+	Operations   int
+	Elapsed      time.Duration
+	OpsPerSecond float64
+}
+
+type Report struct {
+	Goroutines int
+	Unpadded   LayoutResult
+	Padded     LayoutResult
+	// Speedup is padded throughput divided by unpadded throughput.
+	Speedup float64
+}
+
+func (r Report) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "goroutines: %d\n", r.Goroutines)
+	for _, result := range []LayoutResult{r.Unpadded, r.Padded} {
+		fmt.Fprintf(&builder, "%-12s %12d ops %12v %16.0f ops/s\n",
+			result.Name, result.Operations, result.Elapsed, result.OpsPerSecond)
+	}
+	fmt.Fprintf(&builder, "speedup: %.2fx\n", r.Speedup)
+	return builder.String()
+}
+
+// CompareLayouts runs the same number of operations against the
+// unpadded and the padded layout and reports their throughput.
+func CompareLayouts(goroutines, operations int, unpadded, padded Layout) Report {
+	report := Report{
+		Goroutines: goroutines,
+		Unpadded:   measure(goroutines, operations, unpadded),
+		Padded:     measure(goroutines, operations, padded),
+	}
+
+	if report.Unpadded.OpsPerSecond > 0 {
+		report.Speedup = report.Padded.OpsPerSecond / report.Unpadded.OpsPerSecond
+	}
+
+	return report
+}
+
+func measure(goroutines, operations int, layout Layout) LayoutResult {
+	actions := make([]func(), goroutines)
+	for worker := range actions {
+		actions[worker] = layout.Worker(worker)
+	}
+
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for _, action := range actions {
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := 0; i < operations; i++ {
+				action()
+			}
+		}()
+	}
+
+	begin := time.Now()
+	close(start)
+	wg.Wait()
+	elapsed := time.Since(begin)
+
+	total := goroutines * operations
+	result := LayoutResult{
+		Name:       layout.Name,
+		Operations: total,
+		Elapsed:    elapsed,
+	}
+
+	if elapsed > 0 {
+		result.OpsPerSecond = float64(total) / elapsed.Seconds()
+	}
+
+	return result
+}
@@ -0,0 +1,137 @@
+package concurrencytest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+
+	"golang_course/pkg/panics"
+)
+
+// NoYield as Config.YieldProbability switches the injected yields off.
+const NoYield = -1
+
+type Config struct {
+	Goroutines int
+	Iterations int
+	// YieldProbability is the chance in [0, 1] that the harness
+	// calls runtime.Gosched before an iteration or on Yielder.Yield.
+	// Zero means the default 0.1, NoYield or any negative value means 0.
+	YieldProbability float64
+	// Seed makes yield decisions reproducible, zero picks a random seed.
+	Seed uint64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Goroutines <= 0 {
+		c.Goroutines = runtime.GOMAXPROCS(0) * 4
+	}
+	if c.Iterations <= 0 {
+		c.Iterations = 1000
+	}
+	switch {
+	case c.YieldProbability < 0:
+		c.YieldProbability = 0
+	case c.YieldProbability == 0:
+		c.YieldProbability = 0.1
+	}
+	if c.Seed == 0 {
+		c.Seed = rand.Uint64()
+	}
+	return c
+}
+
+// Invariant returns an error if the state under test is inconsistent.
+type Invariant func() error
+
+// Yielder is handed to every goroutine so the code under test can
+// add extra preemption points, e.g. between a load and a store.
+// It must not be shared between goroutines.
+type Yielder struct {
+	random      *rand.Rand
+	probability float64
+}
+
+func (y *Yielder) Yield() {
+	if y.random.Float64() < y.probability {
+		runtime.Gosched()
+	}
+}
+
+// Stress runs fn config.Iterations times in each of config.Goroutines
+// goroutines, all released at the same moment, and then checks the
+// invariants. Panics in fn are reported as errors.
+func Stress(config Config, fn func(y *Yielder, worker, iteration int), invariants ...Invariant) error {
+	config = config.withDefaults()
+
+	start := make(chan struct{})
+	panicked := make(chan error, config.Goroutines)
+
+	wg := sync.WaitGroup{}
+	wg.Add(config.Goroutines)
+	for worker := 0; worker < config.Goroutines; worker++ {
+		yielder := &Yielder{
+			random:      rand.New(rand.NewPCG(config.Seed, uint64(worker))),
+			probability: config.YieldProbability,
+		}
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+			err := panics.SafeCall(func() error {
+				for iteration := 0; iteration < config.Iterations; iteration++ {
+					yielder.Yield()
+					fn(yielder, worker, iteration)
+				}
+				return nil
+			})
+			if err != nil {
+				panicked <- fmt.Errorf("worker %d %w", worker, err)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(panicked)
+
+	var errs []error
+	for err := range panicked {
+		errs = append(errs, err)
+	}
+
+	for _, invariant := range invariants {
+		if err := invariant(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("seed %d: %w", config.Seed, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Verify is Stress for tests: any violation fails t.
+func Verify(t testing.TB, config Config, fn func(y *Yielder, worker, iteration int), invariants ...Invariant) {
+	t.Helper()
+	if err := Stress(config, fn, invariants...); err != nil {
+		t.Error(err)
+	}
+}
+
+// Equal builds an invariant comparing a value read after the run
+// with the expected one.
+func Equal[T comparable](name string, expected T, actual func() T) Invariant {
+	return func() error {
+		if value := actual(); value != expected {
+			return fmt.Errorf("%s: expected %v, got %v", name, expected, value)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+package errorx
+
+// Code classifies errors independently of their messages. It implements
+// error, so errors.Is(err, CodeNotFound) reports whether any error in
+// the chain has the code.
+type Code string
+
+const (
+	CodeUnknown          Code = ""
+	CodeInvalidArgument  Code = "invalid_argument"
+	CodeNotFound         Code = "not_found"
+	CodeAlreadyExists    Code = "already_exists"
+	CodePermissionDenied Code = "permission_denied"
+	CodeUnavailable      Code = "unavailable"
+	CodeDeadlineExceeded Code = "deadline_exceeded"
+	CodeInternal         Code = "internal"
+)
+
+func (c Code) Error() string {
+	if c == CodeUnknown {
+		return "unknown"
+	}
+	return string(c)
+}
+
+func (c Code) String() string {
+	return c.Error()
+}
@@ -0,0 +1,249 @@
+package errorx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const badKey = "!BADKEY"
+
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Error carries a code, key/value fields and the stack of the place it
+// was created at. Wrapping keeps the code and the stack of the cause
+// unless they are given explicitly.
+type Error struct {
+	code    Code
+	message string
+	fields  []Field
+	cause   error
+	stack   Stack
+}
+
+// New creates an error with fields given as alternating keys and values,
+// the same way as in log/slog.
+func New(code Code, message string, keyvals ...any) *Error {
+	return &Error{
+		code:    code,
+		message: message,
+		fields:  toFields(keyvals),
+		stack:   callers(1),
+	}
+}
+
+func Newf(code Code, format string, args ...any) *Error {
+	return &Error{
+		code:    code,
+		message: fmt.Sprintf(format, args...),
+		stack:   callers(1),
+	}
+}
+
+// Sentinel creates an error without a stack, it's intended
+// for package level variables compared with errors.Is.
+func Sentinel(code Code, message string) *Error {
+	return &Error{code: code, message: message}
+}
+
+// Wrap adds a message and fields to err keeping its code.
+// The stack is captured only if the cause doesn't have one.
+// It returns nil if err is nil.
+func Wrap(err error, message string, keyvals ...any) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, CodeOf(err), message, keyvals)
+}
+
+// WrapCode is Wrap which replaces the code of the cause.
+func WrapCode(err error, code Code, message string, keyvals ...any) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, code, message, keyvals)
+}
+
+func wrap(err error, code Code, message string, keyvals []any) *Error {
+	wrapped := &Error{
+		code:    code,
+		message: message,
+		fields:  toFields(keyvals),
+		cause:   err,
+	}
+
+	if StackOf(err) == nil {
+		wrapped.stack = callers(2)
+	}
+	return wrapped
+}
+
+// With returns a copy of the error with additional fields.
+func (e *Error) With(keyvals ...any) *Error {
+	clone := *e
+	clone.fields = append(append([]Field(nil), e.fields...), toFields(keyvals)...)
+	return &clone
+}
+
+func (e *Error) Code() Code {
+	return e.code
+}
+
+func (e *Error) Message() string {
+	return e.message
+}
+
+func (e *Error) Fields() []Field {
+	return append([]Field(nil), e.fields...)
+}
+
+func (e *Error) Stack() Stack {
+	return e.stack
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.cause == nil:
+		return e.message
+	case e.message == "":
+		return e.cause.Error()
+	default:
+		return e.message + ": " + e.cause.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches the code, so errors.Is(err, CodeNotFound) works.
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && code == e.code
+}
+
+// Format supports %s, %q and %v, with %+v the
+// code, the fields and the stack are printed too.
+func (e *Error) Format(state fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if state.Flag('+') {
+			_, _ = io.WriteString(state, e.Error())
+			fmt.Fprintf(state, "\ncode: %s", CodeOf(e))
+			if fields := FieldsOf(e); len(fields) != 0 {
+				fmt.Fprintf(state, "\nfields: %s", joinFields(fields))
+			}
+			if stack := StackOf(e); stack != nil {
+				fmt.Fprintf(state, "\nstack:%+v", stack)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(state, e.Error())
+	case 'q':
+		fmt.Fprintf(state, "%q", e.Error())
+	}
+}
+
+// MarshalJSON writes the error as an object suitable for structured logs.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]any)
+	for _, field := range FieldsOf(e) {
+		value := field.Value
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[field.Key] = value
+	}
+
+	return json.Marshal(struct {
+		Code    Code           `json:"code"`
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields,omitempty"`
+		Stack   []string       `json:"stack,omitempty"`
+	}{
+		Code:    CodeOf(e),
+		Message: e.Error(),
+		Fields:  fields,
+		Stack:   StackOf(e).strings(),
+	})
+}
+
+// CodeOf returns the code of the outermost Error in the chain.
+func CodeOf(err error) Code {
+	var target *Error
+	if errors.As(err, &target) {
+		return target.code
+	}
+	return CodeUnknown
+}
+
+// FieldsOf collects the fields of all errors in the chain, from the
+// outermost to the innermost. If a key repeats, the outer value wins.
+func FieldsOf(err error) []Field {
+	var fields []Field
+	seen := make(map[string]bool)
+	for err != nil {
+		if target, ok := err.(*Error); ok {
+			for _, field := range target.fields {
+				if !seen[field.Key] {
+					seen[field.Key] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return fields
+}
+
+// StackOf returns the innermost stack in the chain, which
+// is the closest one to the place the failure happened.
+func StackOf(err error) Stack {
+	var stack Stack
+	for err != nil {
+		if target, ok := err.(*Error); ok && target.stack != nil {
+			stack = target.stack
+		}
+		err = errors.Unwrap(err)
+	}
+	return stack
+}
+
+func toFields(keyvals []any) []Field {
+	if len(keyvals) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for len(keyvals) > 0 {
+		key, ok := keyvals[0].(string)
+		if !ok || len(keyvals) == 1 {
+			fields = append(fields, Field{Key: badKey, Value: keyvals[0]})
+			keyvals = keyvals[1:]
+			continue
+		}
+
+		fields = append(fields, Field{Key: key, Value: keyvals[1]})
+		keyvals = keyvals[2:]
+	}
+	return fields
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%s=%v", f.Key, f.Value)
+}
+
+func joinFields(fields []Field) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field.String())
+	}
+	return strings.Join(parts, " ")
+}
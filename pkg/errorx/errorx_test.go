@@ -0,0 +1,183 @@
+package errorx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ErrUserNotFound = Sentinel(CodeNotFound, "user not found")
+
+func findUser(id int) error {
+	return New(CodeNotFound, "user not found", "user_id", id)
+}
+
+func TestNew(t *testing.T) {
+	err := findUser(42)
+
+	assert.EqualError(t, err, "user not found")
+	assert.Equal(t, CodeNotFound, CodeOf(err))
+	assert.Equal(t, []Field{{Key: "user_id", Value: 42}}, FieldsOf(err))
+
+	frames := StackOf(err).Frames()
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, "errorx.findUser"), frames[0].Function)
+}
+
+func TestWrapKeepsCodeAndStack(t *testing.T) {
+	cause := findUser(42)
+	err := Wrap(cause, "load profile", "request_id", "12-21-33")
+	err = fmt.Errorf("handler: %w", err)
+
+	assert.EqualError(t, err, "handler: load profile: user not found")
+	assert.Equal(t, CodeNotFound, CodeOf(err))
+	assert.Equal(t, StackOf(cause), StackOf(err))
+	assert.Equal(t, []Field{
+		{Key: "request_id", Value: "12-21-33"},
+		{Key: "user_id", Value: 42},
+	}, FieldsOf(err))
+
+	err = WrapCode(cause, CodeInternal, "")
+	assert.EqualError(t, err, "user not found")
+	assert.Equal(t, CodeInternal, CodeOf(err))
+	assert.ErrorIs(t, err, CodeNotFound)
+}
+
+func TestWrapCapturesStackOfPlainErrors(t *testing.T) {
+	err := Wrap(io.EOF, "read header")
+	frames := StackOf(err).Frames()
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, "TestWrapCapturesStackOfPlainErrors"), frames[0].Function)
+	assert.Equal(t, CodeUnknown, CodeOf(err))
+
+	assert.Nil(t, Wrap(nil, "nothing"))
+	assert.Nil(t, WrapCode(nil, CodeInternal, "nothing"))
+}
+
+func TestIsAs(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/config", Err: os.ErrNotExist}
+	err := WrapCode(pathErr, CodeUnavailable, "load config")
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorIs(t, err, CodeUnavailable)
+	assert.NotErrorIs(t, err, CodeNotFound)
+
+	var target *fs.PathError
+	require.ErrorAs(t, err, &target)
+	assert.Equal(t, "/etc/config", target.Path)
+
+	var structured *Error
+	require.ErrorAs(t, fmt.Errorf("wrapped: %w", err), &structured)
+	assert.Equal(t, "load config", structured.Message())
+
+	err = Wrap(ErrUserNotFound, "get user")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, err, CodeNotFound)
+}
+
+func TestWith(t *testing.T) {
+	base := New(CodeInvalidArgument, "invalid value", "field", "age")
+	err := base.With("value", -1)
+
+	assert.Equal(t, []Field{{Key: "field", Value: "age"}}, base.Fields())
+	assert.Equal(t, []Field{{Key: "field", Value: "age"}, {Key: "value", Value: -1}}, err.Fields())
+	assert.Equal(t, base.Stack(), err.Stack())
+}
+
+func TestBadKeys(t *testing.T) {
+	err := New(CodeInternal, "failure", 1, "value", "dangling")
+	assert.Equal(t, []Field{
+		{Key: badKey, Value: 1},
+		{Key: "value", Value: "dangling"},
+	}, err.Fields())
+
+	err = New(CodeInternal, "failure", "key", "value", "dangling")
+	assert.Equal(t, []Field{
+		{Key: "key", Value: "value"},
+		{Key: badKey, Value: "dangling"},
+	}, err.Fields())
+}
+
+func TestFormat(t *testing.T) {
+	err := Wrap(findUser(42), "load profile", "attempt", 2)
+
+	assert.Equal(t, "load profile: user not found", fmt.Sprintf("%s", err))
+	assert.Equal(t, "load profile: user not found", fmt.Sprintf("%v", err))
+	assert.Equal(t, `"load profile: user not found"`, fmt.Sprintf("%q", err))
+
+	detailed := fmt.Sprintf("%+v", err)
+	assert.True(t, strings.HasPrefix(detailed, "load profile: user not found\ncode: not_found\nfields: attempt=2 user_id=42\nstack:\n"), detailed)
+	assert.Contains(t, detailed, "errorx.findUser\n\t")
+	assert.Contains(t, detailed, "errorx_test.go:")
+}
+
+func TestMarshalJSON(t *testing.T) {
+	err := Wrap(findUser(42), "load profile", "cause", io.EOF)
+
+	data, marshalErr := json.Marshal(err)
+	require.NoError(t, marshalErr)
+
+	var decoded struct {
+		Code    string         `json:"code"`
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+		Stack   []string       `json:"stack"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	assert.Equal(t, "not_found", decoded.Code)
+	assert.Equal(t, "load profile: user not found", decoded.Message)
+	assert.Equal(t, map[string]any{"cause": "EOF", "user_id": float64(42)}, decoded.Fields)
+	require.NotEmpty(t, decoded.Stack)
+	assert.Contains(t, decoded.Stack[0], "errorx.findUser")
+
+	data, marshalErr = json.Marshal(ErrUserNotFound)
+	require.NoError(t, marshalErr)
+	assert.JSONEq(t, `{"code":"not_found","message":"user not found"}`, string(data))
+}
+
+func TestSetCaptureStacks(t *testing.T) {
+	previous := SetCaptureStacks(false)
+	defer SetCaptureStacks(previous)
+
+	err := New(CodeInternal, "failure")
+	assert.Nil(t, StackOf(err))
+	assert.Nil(t, StackOf(Wrap(io.EOF, "read")))
+	assert.NotContains(t, fmt.Sprintf("%+v", err), "stack:")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = New(CodeInternal, "failure")
+	})
+	assert.Equal(t, float64(1), allocs)
+}
+
+func TestCodeString(t *testing.T) {
+	assert.Equal(t, "not_found", CodeNotFound.String())
+	assert.Equal(t, "unknown", CodeUnknown.String())
+	assert.Equal(t, CodeUnknown, CodeOf(errors.New("plain")))
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.Run("stack", func(b *testing.B) {
+		for range b.N {
+			_ = New(CodeInternal, "failure")
+		}
+	})
+
+	b.Run("no stack", func(b *testing.B) {
+		previous := SetCaptureStacks(false)
+		defer SetCaptureStacks(previous)
+
+		for range b.N {
+			_ = New(CodeInternal, "failure")
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package errorx
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+var captureStacks atomic.Bool
+
+func init() {
+	captureStacks.Store(true)
+}
+
+// SetCaptureStacks turns stack capture on or off for the whole program
+// and returns the previous setting. Without stacks creating an error
+// costs a single allocation, which matters on hot paths.
+func SetCaptureStacks(enabled bool) bool {
+	return captureStacks.Swap(enabled)
+}
+
+// Stack holds the program counters of the calls leading to an error.
+type Stack []uintptr
+
+func callers(skip int) Stack {
+	if !captureStacks.Load() {
+		return nil
+	}
+
+	var pcs [maxStackDepth]uintptr
+	count := runtime.Callers(skip+2, pcs[:])
+	return append(Stack(nil), pcs[:count]...)
+}
+
+func (s Stack) Frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var result []runtime.Frame
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		result = append(result, frame)
+		if !more {
+			return result
+		}
+	}
+}
+
+// Format prints a frame per line with %+v, in the same
+// layout as panics do, and the list of functions otherwise.
+func (s Stack) Format(state fmt.State, verb rune) {
+	for idx, frame := range s.Frames() {
+		if verb == 'v' && state.Flag('+') {
+			fmt.Fprintf(state, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+			continue
+		}
+
+		if idx > 0 {
+			_, _ = io.WriteString(state, " ")
+		}
+		_, _ = io.WriteString(state, frame.Function)
+	}
+}
+
+func (s Stack) strings() []string {
+	frames := s.Frames()
+	result := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		result = append(result, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+	}
+	return result
+}
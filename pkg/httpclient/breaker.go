@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker opens after threshold consecutive failures and rejects calls
+// for openTimeout. After that a single probe is let through: its success
+// closes the breaker, its failure opens it again.
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mutex    sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	// generation changes with every state change and every probe,
+	// outcomes of permits from other generations are ignored
+	generation uint64
+}
+
+// Permit is returned by Allow and identifies the call whose outcome
+// is reported, so a late outcome of a call made before the breaker
+// opened isn't taken for the outcome of the probe.
+type Permit struct {
+	generation uint64
+}
+
+func NewBreaker(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   max(threshold, 1),
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen if the call must not be made. Otherwise
+// the caller has to report the outcome with Success or Failure.
+func (b *Breaker) Allow() (Permit, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	switch b.state {
+	case StateOpen:
+		return Permit{}, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probing {
+			return Permit{}, ErrCircuitOpen
+		}
+		b.probing = true
+		b.generation++
+	}
+
+	return Permit{generation: b.generation}, nil
+}
+
+func (b *Breaker) Success(permit Permit) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if permit.generation != b.generation {
+		return
+	}
+
+	if b.state == StateHalfOpen {
+		b.state = StateClosed
+		b.probing = false
+		b.generation++
+	}
+	b.failures = 0
+}
+
+func (b *Breaker) Failure(permit Permit) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if permit.generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		b.open()
+	}
+}
+
+// release ends a probe without an outcome, e.g. when the caller
+// cancelled the request, so another probe can be made.
+func (b *Breaker) release(permit Permit) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if permit.generation == b.generation && b.state == StateHalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.probing = false
+	b.generation++
+}
+
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.state = StateHalfOpen
+	}
+}
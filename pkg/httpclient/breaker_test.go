@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(duration time.Duration) {
+	c.now = c.now.Add(duration)
+}
+
+func newTestBreaker(threshold int, openTimeout time.Duration) (*Breaker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewBreaker(threshold, openTimeout)
+	breaker.now = clock.Now
+	return breaker, clock
+}
+
+// allow asserts that the call is allowed and returns its permit
+func allow(t *testing.T, breaker *Breaker) Permit {
+	t.Helper()
+	permit, err := breaker.Allow()
+	require.NoError(t, err)
+	return permit
+}
+
+func assertRejected(t *testing.T, breaker *Breaker) {
+	t.Helper()
+	_, err := breaker.Allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	breaker, _ := newTestBreaker(3, time.Second)
+
+	for range 2 {
+		breaker.Failure(allow(t, breaker))
+	}
+
+	// a success resets the counter
+	breaker.Success(allow(t, breaker))
+
+	for range 3 {
+		assert.Equal(t, StateClosed, breaker.State())
+		breaker.Failure(allow(t, breaker))
+	}
+
+	assert.Equal(t, StateOpen, breaker.State())
+	assertRejected(t, breaker)
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	breaker, clock := newTestBreaker(1, time.Second)
+
+	breaker.Failure(allow(t, breaker))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	clock.Advance(999 * time.Millisecond)
+	assertRejected(t, breaker)
+
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	// only a single probe at a time
+	probe := allow(t, breaker)
+	assertRejected(t, breaker)
+
+	// the failed probe opens the breaker for the whole timeout again
+	breaker.Failure(probe)
+	assert.Equal(t, StateOpen, breaker.State())
+	clock.Advance(500 * time.Millisecond)
+	assertRejected(t, breaker)
+
+	clock.Advance(500 * time.Millisecond)
+	breaker.Success(allow(t, breaker))
+
+	assert.Equal(t, StateClosed, breaker.State())
+	allow(t, breaker)
+	allow(t, breaker)
+}
+
+func TestBreakerReleasedProbe(t *testing.T) {
+	breaker, clock := newTestBreaker(1, time.Second)
+
+	breaker.Failure(allow(t, breaker))
+	clock.Advance(time.Second)
+
+	probe := allow(t, breaker)
+	breaker.release(probe)
+
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	next := allow(t, breaker)
+
+	// the released probe can't report for the next one
+	breaker.Success(probe)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	breaker.Success(next)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreakerIgnoresLateSuccess(t *testing.T) {
+	breaker, _ := newTestBreaker(1, time.Second)
+
+	first := allow(t, breaker)
+	second := allow(t, breaker)
+	breaker.Failure(first)
+	breaker.Success(second)
+
+	assert.Equal(t, StateOpen, breaker.State())
+}
+
+func TestBreakerStaleSuccessDuringProbe(t *testing.T) {
+	breaker, clock := newTestBreaker(1, time.Second)
+
+	stale := allow(t, breaker)
+	breaker.Failure(allow(t, breaker))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	clock.Advance(time.Second)
+	probe := allow(t, breaker)
+
+	// a call made before the breaker opened finishes during the probe
+	breaker.Success(stale)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	assertRejected(t, breaker)
+
+	breaker.Failure(probe)
+	assert.Equal(t, StateOpen, breaker.State())
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown", State(10).String())
+}
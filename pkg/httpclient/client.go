@@ -0,0 +1,236 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRetries       = 3
+	DefaultBaseDelay        = 100 * time.Millisecond
+	DefaultMaxDelay         = 5 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// Client retries idempotent requests with exponential backoff and
+// keeps a circuit breaker per host. The zero value sends every request
+// once without a breaker, New returns a client with the defaults.
+type Client struct {
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	MaxRetries int
+	// BaseDelay is doubled after every attempt up to MaxDelay, the actual
+	// delay is picked randomly from the upper half of it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FailureThreshold consecutive failures of a host open its breaker
+	// for OpenTimeout. Zero disables the breakers.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	mutex    sync.Mutex
+	breakers map[string]*Breaker
+	now      func() time.Time
+}
+
+func New() *Client {
+	return &Client{
+		MaxRetries:       DefaultMaxRetries,
+		BaseDelay:        DefaultBaseDelay,
+		MaxDelay:         DefaultMaxDelay,
+		FailureThreshold: DefaultFailureThreshold,
+		OpenTimeout:      DefaultOpenTimeout,
+	}
+}
+
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(request)
+}
+
+// Do sends the request, retrying it on network errors and on 429 and
+// 5xx responses if it's idempotent. A retry is not made if it can't
+// start before the context deadline, then the last result is returned.
+func (c *Client) Do(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	breaker := c.Breaker(request.URL.Host)
+	retryable := isIdempotent(request) && isRewindable(request)
+
+	for attempt := 0; ; attempt++ {
+		var permit Permit
+		if breaker != nil {
+			var err error
+			if permit, err = breaker.Allow(); err != nil {
+				return nil, fmt.Errorf("%w: %s", err, request.URL.Host)
+			}
+		}
+
+		response, err := c.send(request, attempt)
+		if breaker != nil {
+			switch {
+			case err != nil && ctx.Err() != nil:
+				breaker.release(permit)
+			case err != nil || response.StatusCode >= http.StatusInternalServerError:
+				breaker.Failure(permit)
+			default:
+				breaker.Success(permit)
+			}
+		}
+
+		if !retryable || attempt >= c.MaxRetries || !shouldRetry(ctx, response, err) {
+			return response, err
+		}
+
+		delay := c.backoff(attempt)
+		if after, ok := retryAfter(response, c.clock()); ok {
+			delay = max(delay, after)
+		}
+		if deadline, ok := ctx.Deadline(); ok && c.clock().Add(delay).After(deadline) {
+			return response, err
+		}
+
+		if response != nil {
+			// the connection can be reused only if the body is read to the end
+			_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+			response.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(request *http.Request, attempt int) (*http.Response, error) {
+	if attempt > 0 {
+		request = request.Clone(request.Context())
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(request)
+}
+
+// Breaker returns the breaker of the host, nil if breakers are disabled.
+func (c *Client) Breaker(host string) *Breaker {
+	if c.FailureThreshold <= 0 {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.breakers == nil {
+		c.breakers = make(map[string]*Breaker)
+	}
+
+	breaker, ok := c.breakers[host]
+	if !ok {
+		breaker = NewBreaker(c.FailureThreshold, c.OpenTimeout)
+		if c.now != nil {
+			breaker.now = c.now
+		}
+		c.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// backoff returns a random delay between the half and the whole of
+// BaseDelay * 2^attempt, so clients failed together don't retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.BaseDelay
+	for range attempt {
+		if c.MaxDelay > 0 && delay >= c.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if c.MaxDelay > 0 {
+		delay = min(delay, c.MaxDelay)
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (c *Client) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// isIdempotent follows RFC 9110, other requests can
+// be marked as safe to retry with an Idempotency-Key header.
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return request.Header.Get("Idempotency-Key") != ""
+}
+
+func isRewindable(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+func shouldRetry(ctx context.Context, response *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the header given either in seconds or as a date.
+func retryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,288 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faultServer answers with the scripted statuses in order and with 200
+// after them. A zero status drops the connection without a response.
+type faultServer struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	statuses   []int
+	retryAfter string
+	calls      atomic.Int32
+	bodies     []string
+}
+
+func newFaultServer(t *testing.T, statuses ...int) *faultServer {
+	server := &faultServer{statuses: statuses}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *faultServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.calls.Add(1)
+	body, _ := io.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	s.bodies = append(s.bodies, string(body))
+	status := http.StatusOK
+	if len(s.statuses) != 0 {
+		status = s.statuses[0]
+		s.statuses = s.statuses[1:]
+	}
+	retryAfter := s.retryAfter
+	s.mutex.Unlock()
+
+	if status == 0 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+
+	if retryAfter != "" && status != http.StatusOK {
+		w.Header().Set("Retry-After", retryAfter)
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, http.StatusText(status))
+}
+
+func newTestClient() *Client {
+	return &Client{
+		MaxRetries: 3,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   10 * time.Millisecond,
+	}
+}
+
+func TestClientRetriesFailures(t *testing.T) {
+	tests := map[string][]int{
+		"unavailable":       {http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		"too many requests": {http.StatusTooManyRequests},
+		"bad gateway":       {http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError},
+		"dropped":           {0, 0},
+	}
+
+	for name, statuses := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newFaultServer(t, statuses...)
+
+			response, err := newTestClient().Get(context.Background(), server.URL)
+			require.NoError(t, err)
+			defer response.Body.Close()
+
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, int32(len(statuses)+1), server.calls.Load())
+		})
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	server := newFaultServer(t, 500, 500, 500, 500, 500)
+
+	response, err := newTestClient().Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(t, "Internal Server Error", string(body))
+	assert.Equal(t, int32(4), server.calls.Load())
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	server := newFaultServer(t, http.StatusNotFound)
+
+	response, err := newTestClient().Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Equal(t, int32(1), server.calls.Load())
+}
+
+func TestClientRetriesOnlyIdempotentRequests(t *testing.T) {
+	server := newFaultServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	client := newTestClient()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(1), server.calls.Load())
+
+	// the body is sent again with every attempt
+	request, err = http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	request.Header.Set("Idempotency-Key", "12-21-33")
+
+	response, err = client.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"payload", "payload", "payload"}, server.bodies)
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	server := newFaultServer(t, http.StatusServiceUnavailable)
+	server.retryAfter = "1"
+
+	start := time.Now()
+	response, err := newTestClient().Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestClientStopsBeforeDeadline(t *testing.T) {
+	server := newFaultServer(t, http.StatusServiceUnavailable)
+	server.retryAfter = "10"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// waiting for Retry-After would outlive the deadline,
+	// so the last response is returned right away
+	start := time.Now()
+	response, err := newTestClient().Get(ctx, server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), server.calls.Load())
+}
+
+func TestClientCancelledDuringBackoff(t *testing.T) {
+	server := newFaultServer(t, http.StatusServiceUnavailable)
+	client := newTestClient()
+	client.BaseDelay = time.Minute
+	client.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	response, err := client.Get(ctx, server.URL)
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), server.calls.Load())
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	server := newFaultServer(t, 500, 500, 500, 500)
+	healthy := newFaultServer(t)
+
+	now := time.Now()
+	client := newTestClient()
+	client.MaxRetries = 1
+	client.FailureThreshold = 3
+	client.OpenTimeout = time.Minute
+	client.now = func() time.Time { return now }
+
+	// two requests with a retry each, the breaker opens on the third failure
+	response, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	_, err = client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), server.calls.Load())
+	assert.Equal(t, StateOpen, client.Breaker(server.Listener.Addr().String()).State())
+
+	// the breakers are per host
+	response, err = client.Get(context.Background(), healthy.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the probe fails and the breaker opens again without retries
+	now = now.Add(time.Minute)
+	_, err = client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(4), server.calls.Load())
+
+	now = now.Add(time.Minute)
+	response, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, StateClosed, client.Breaker(server.Listener.Addr().String()).State())
+}
+
+func TestClientWithoutRetries(t *testing.T) {
+	server := newFaultServer(t, 0)
+
+	var client Client
+	_, err := client.Get(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(1), server.calls.Load())
+	assert.Nil(t, client.Breaker(server.Listener.Addr().String()))
+}
+
+func TestBackoff(t *testing.T) {
+	client := &Client{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for attempt, limit := range expected {
+		limit *= time.Millisecond
+		for range 100 {
+			delay := client.backoff(attempt)
+			assert.GreaterOrEqual(t, delay, limit/2)
+			assert.LessOrEqual(t, delay, limit)
+		}
+	}
+
+	assert.Equal(t, time.Duration(0), (&Client{}).backoff(5))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		"seconds":  {value: "120", expected: 2 * time.Minute, ok: true},
+		"date":     {value: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, ok: true},
+		"past":     {value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+		"missing":  {value: ""},
+		"negative": {value: "-1"},
+		"invalid":  {value: "soon"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			response := &http.Response{Header: http.Header{}}
+			if test.value != "" {
+				response.Header.Set("Retry-After", test.value)
+			}
+
+			delay, ok := retryAfter(response, now)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, delay)
+		})
+	}
+}
@@ -0,0 +1,399 @@
+// Package ipaddr parses and formats IPv4 and IPv6 addresses and CIDR
+// prefixes and provides a longest-prefix-match routing table.
+package ipaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAddress = errors.New("ipaddr: invalid address")
+
+// Addr is an IPv4 or IPv6 address, comparable with ==.
+// The zero value is not a valid address.
+type Addr struct {
+	// IPv4 addresses are kept in the lower 32 bits of lo
+	hi, lo uint64
+	// bitLen is 32 for IPv4, 128 for IPv6 and 0 for the zero value
+	bitLen uint8
+}
+
+func AddrFrom4(octets [4]byte) Addr {
+	return AddrFromUint32(uint32(octets[0])<<24 | uint32(octets[1])<<16 | uint32(octets[2])<<8 | uint32(octets[3]))
+}
+
+func AddrFromUint32(value uint32) Addr {
+	return Addr{lo: uint64(value), bitLen: 32}
+}
+
+func AddrFrom16(octets [16]byte) Addr {
+	var addr Addr
+	for idx := range 8 {
+		addr.hi = addr.hi<<8 | uint64(octets[idx])
+		addr.lo = addr.lo<<8 | uint64(octets[idx+8])
+	}
+	addr.bitLen = 128
+	return addr
+}
+
+// ParseAddr accepts dotted decimal IPv4 without leading zeros and IPv6
+// in the RFC 4291 text forms, including a trailing dotted IPv4 part.
+// Zones like "%eth0" are not supported.
+func ParseAddr(s string) (Addr, error) {
+	for idx := 0; idx < len(s); idx++ {
+		switch s[idx] {
+		case '.':
+			return parse4(s)
+		case ':':
+			return parse6(s)
+		}
+	}
+	return Addr{}, parseError(s, "no '.' or ':'")
+}
+
+func MustParseAddr(s string) Addr {
+	addr, err := ParseAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+func parseError(s, reason string) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidAddress, s, reason)
+}
+
+func parse4(s string) (Addr, error) {
+	value, err := parseDotted(s)
+	if err != nil {
+		return Addr{}, parseError(s, err.Error())
+	}
+	return AddrFromUint32(value), nil
+}
+
+func parseDotted(s string) (uint32, error) {
+	var value uint32
+	fields := strings.Split(s, ".")
+	if len(fields) != 4 {
+		return 0, errors.New("IPv4 needs 4 fields")
+	}
+
+	for _, field := range fields {
+		if field == "" || len(field) > 3 {
+			return 0, errors.New("IPv4 field must have 1 to 3 digits")
+		}
+		if len(field) > 1 && field[0] == '0' {
+			return 0, errors.New("IPv4 field has a leading zero")
+		}
+
+		octet := 0
+		for idx := 0; idx < len(field); idx++ {
+			if field[idx] < '0' || field[idx] > '9' {
+				return 0, errors.New("unexpected character")
+			}
+			octet = 10*octet + int(field[idx]-'0')
+		}
+		if octet > 255 {
+			return 0, errors.New("IPv4 field is greater than 255")
+		}
+
+		value = value<<8 | uint32(octet)
+	}
+	return value, nil
+}
+
+func parse6(original string) (Addr, error) {
+	var groups [8]uint16
+	count := 0
+	ellipsis := -1
+
+	s := original
+	if strings.HasPrefix(s, "::") {
+		ellipsis = 0
+		s = s[2:]
+	}
+
+	for s != "" {
+		size := 0
+		for size < len(s) && isHex(s[size]) {
+			size++
+		}
+
+		if size < len(s) && s[size] == '.' {
+			// the last 32 bits can be written as IPv4
+			if count > 6 {
+				return Addr{}, parseError(original, "too many fields")
+			}
+			value, err := parseDotted(s)
+			if err != nil {
+				return Addr{}, parseError(original, err.Error())
+			}
+			groups[count] = uint16(value >> 16)
+			groups[count+1] = uint16(value)
+			count += 2
+			break
+		}
+
+		switch {
+		case size == 0:
+			return Addr{}, parseError(original, "empty field")
+		case size > 4:
+			return Addr{}, parseError(original, "field has more than 4 digits")
+		case count == 8:
+			return Addr{}, parseError(original, "too many fields")
+		}
+
+		group, _ := strconv.ParseUint(s[:size], 16, 16)
+		groups[count] = uint16(group)
+		count++
+
+		s = s[size:]
+		if s == "" {
+			break
+		}
+		if s[0] != ':' {
+			return Addr{}, parseError(original, "unexpected character")
+		}
+
+		s = s[1:]
+		switch {
+		case s == "":
+			return Addr{}, parseError(original, "trailing ':'")
+		case s[0] == ':':
+			if ellipsis >= 0 {
+				return Addr{}, parseError(original, "multiple '::'")
+			}
+			ellipsis = count
+			s = s[1:]
+		}
+	}
+
+	if ellipsis >= 0 {
+		// "::" stands for at least one zero field
+		if count == 8 {
+			return Addr{}, parseError(original, "'::' with 8 fields")
+		}
+		shift := 8 - count
+		copy(groups[ellipsis+shift:], groups[ellipsis:count])
+		clear(groups[ellipsis : ellipsis+shift])
+	} else if count != 8 {
+		return Addr{}, parseError(original, "too few fields")
+	}
+
+	addr := Addr{bitLen: 128}
+	for idx := range 4 {
+		addr.hi = addr.hi<<16 | uint64(groups[idx])
+		addr.lo = addr.lo<<16 | uint64(groups[idx+4])
+	}
+	return addr, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func (a Addr) IsValid() bool {
+	return a.bitLen != 0
+}
+
+func (a Addr) Is4() bool {
+	return a.bitLen == 32
+}
+
+func (a Addr) Is6() bool {
+	return a.bitLen == 128
+}
+
+// Is4In6 reports whether the address is an IPv4-mapped IPv6 address ::ffff:a.b.c.d.
+func (a Addr) Is4In6() bool {
+	return a.Is6() && a.hi == 0 && a.lo>>32 == 0xffff
+}
+
+// Unmap returns the IPv4 address of an IPv4-mapped one and a otherwise.
+func (a Addr) Unmap() Addr {
+	if a.Is4In6() {
+		return AddrFromUint32(uint32(a.lo))
+	}
+	return a
+}
+
+// BitLen returns 32 for IPv4, 128 for IPv6 and 0 for the zero value.
+func (a Addr) BitLen() int {
+	return int(a.bitLen)
+}
+
+// Uint32 returns the IPv4 address as a number, 0 for IPv6.
+func (a Addr) Uint32() uint32 {
+	if !a.Is4() {
+		return 0
+	}
+	return uint32(a.lo)
+}
+
+// As4 returns the octets of an IPv4 or IPv4-mapped address.
+func (a Addr) As4() [4]byte {
+	value := uint32(a.lo)
+	return [4]byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+}
+
+// As16 returns IPv4 addresses in the IPv4-mapped form.
+func (a Addr) As16() [16]byte {
+	hi, lo := a.hi, a.lo
+	if a.Is4() {
+		lo |= 0xffff << 32
+	}
+
+	var octets [16]byte
+	for idx := range 8 {
+		octets[7-idx] = byte(hi >> (8 * idx))
+		octets[15-idx] = byte(lo >> (8 * idx))
+	}
+	return octets
+}
+
+// bit returns the bit at the position counted from the most significant one.
+func (a Addr) bit(position int) int {
+	if a.Is4() {
+		return int(a.lo>>(31-position)) & 1
+	}
+	if position < 64 {
+		return int(a.hi>>(63-position)) & 1
+	}
+	return int(a.lo>>(127-position)) & 1
+}
+
+// commonBits returns the length of the common prefix of two
+// addresses of the same family.
+func (a Addr) commonBits(other Addr) int {
+	if a.Is4() {
+		return bits.LeadingZeros32(uint32(a.lo ^ other.lo))
+	}
+	if diff := a.hi ^ other.hi; diff != 0 {
+		return bits.LeadingZeros64(diff)
+	}
+	return 64 + bits.LeadingZeros64(a.lo^other.lo)
+}
+
+// mask keeps the first count bits of the address.
+func (a Addr) mask(count int) Addr {
+	if a.Is4() {
+		a.lo &= uint64(^uint32(0) << (32 - count))
+		return a
+	}
+
+	switch {
+	case count <= 64:
+		a.hi &= ^uint64(0) << (64 - count)
+		a.lo = 0
+	default:
+		a.lo &= ^uint64(0) << (128 - count)
+	}
+	return a
+}
+
+// Compare orders addresses by family, IPv4 first, and then by value.
+func (a Addr) Compare(other Addr) int {
+	switch {
+	case a.bitLen != other.bitLen:
+		return compare(a.bitLen, other.bitLen)
+	case a.hi != other.hi:
+		return compare(a.hi, other.hi)
+	default:
+		return compare(a.lo, other.lo)
+	}
+}
+
+func compare[T uint8 | uint64](lhs, rhs T) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats IPv6 as recommended by RFC 5952: lowercase, without
+// leading zeros and with the longest run of zero fields replaced by "::".
+func (a Addr) String() string {
+	switch {
+	case a.Is4():
+		return string(a.append4(nil))
+	case a.Is4In6():
+		return string(a.append4([]byte("::ffff:")))
+	case a.Is6():
+		return string(a.append6(nil))
+	default:
+		return "invalid Addr"
+	}
+}
+
+func (a Addr) append4(buffer []byte) []byte {
+	for idx, octet := range a.As4() {
+		if idx > 0 {
+			buffer = append(buffer, '.')
+		}
+		buffer = strconv.AppendUint(buffer, uint64(octet), 10)
+	}
+	return buffer
+}
+
+func (a Addr) append6(buffer []byte) []byte {
+	var groups [8]uint16
+	for idx := range 4 {
+		groups[3-idx] = uint16(a.hi >> (16 * idx))
+		groups[7-idx] = uint16(a.lo >> (16 * idx))
+	}
+
+	// the first longest run of at least two zero groups
+	bestStart, bestLen := -1, 1
+	for start := 0; start < 8; {
+		end := start
+		for end < 8 && groups[end] == 0 {
+			end++
+		}
+		if end-start > bestLen {
+			bestStart, bestLen = start, end-start
+		}
+		start = end + 1
+	}
+
+	for idx := 0; idx < 8; idx++ {
+		if idx == bestStart {
+			buffer = append(buffer, "::"...)
+			idx += bestLen - 1
+			continue
+		}
+		if idx > 0 && idx != bestStart+bestLen {
+			buffer = append(buffer, ':')
+		}
+		buffer = strconv.AppendUint(buffer, uint64(groups[idx]), 16)
+	}
+	return buffer
+}
+
+func (a Addr) MarshalText() ([]byte, error) {
+	if !a.IsValid() {
+		return []byte{}, nil
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText turns an empty text into the zero Addr.
+func (a *Addr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = Addr{}
+		return nil
+	}
+
+	addr, err := ParseAddr(string(text))
+	if err != nil {
+		return err
+	}
+	*a = addr
+	return nil
+}
@@ -0,0 +1,378 @@
+package ipaddr
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toNetip converts for the comparisons with the standard library.
+func toNetip(addr Addr) netip.Addr {
+	if addr.Is4() {
+		return netip.AddrFrom4(addr.As4())
+	}
+	return netip.AddrFrom16(addr.As16())
+}
+
+func randomAddr(random *rand.Rand) Addr {
+	if random.IntN(2) == 0 {
+		return AddrFromUint32(random.Uint32())
+	}
+
+	var octets [16]byte
+	for idx := range octets {
+		// zero groups make "::" compression interesting
+		if random.IntN(3) > 0 {
+			octets[idx] = byte(random.Uint32())
+		}
+	}
+	return AddrFrom16(octets)
+}
+
+func TestParseAddr(t *testing.T) {
+	tests := map[string]string{
+		"0.0.0.0":                   "0.0.0.0",
+		"192.168.1.10":              "192.168.1.10",
+		"255.255.255.255":           "255.255.255.255",
+		"::":                        "::",
+		"::1":                       "::1",
+		"1::":                       "1::",
+		"2001:DB8::8:800:200C:417A": "2001:db8::8:800:200c:417a",
+		"2001:0db8:0000:0000:0000:0000:0000:0001": "2001:db8::1",
+		"2001:db8:0:1:1:1:1:1":                    "2001:db8:0:1:1:1:1:1",
+		"2001:0:0:1:0:0:0:1":                      "2001:0:0:1::1",
+		"2001:db8:0:0:1:0:0:1":                    "2001:db8::1:0:0:1",
+		"1:2:3:4:5:6:7::":                         "1:2:3:4:5:6:7:0",
+		"::ffff:192.0.2.1":                        "::ffff:192.0.2.1",
+		"::192.0.2.1":                             "::c000:201",
+		"64:ff9b::192.0.2.33":                     "64:ff9b::c000:221",
+	}
+
+	for input, expected := range tests {
+		addr, err := ParseAddr(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, addr.String(), input)
+		assert.Equal(t, netip.MustParseAddr(input).String(), addr.String(), input)
+	}
+}
+
+func TestParseAddrErrors(t *testing.T) {
+	inputs := []string{
+		"", "1", "1.2.3", "1.2.3.4.5", "256.0.0.1", "01.2.3.4", "1.2.3.-4", "1..2.3", "1.2.3.4 ",
+		":", ":::", "1:2", ":1::", "1::2::3", "1:2:3:4:5:6:7:8:9", "1:2:3:4:5:6:7:8::", "12345::",
+		"1:2:3:4:5:6:7:", "g::", "1.2.3.4::", "::1.2.3.4:5", "1:2:3:4:5:6:7:1.2.3.4",
+	}
+
+	for _, input := range inputs {
+		_, err := ParseAddr(input)
+		assert.ErrorIs(t, err, ErrInvalidAddress, input)
+		_, expectedErr := netip.ParseAddr(input)
+		assert.Error(t, expectedErr, input)
+	}
+
+	// netip accepts zones, but they are not supported here
+	_, err := ParseAddr("fe80::1%eth0")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+func TestAddrMatchesNetip(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	for range 10000 {
+		addr := randomAddr(random)
+		expected := toNetip(addr)
+
+		require.Equal(t, expected.String(), addr.String())
+		require.Equal(t, expected.BitLen(), addr.BitLen())
+		require.Equal(t, expected.Is4In6(), addr.Is4In6())
+
+		parsed, err := ParseAddr(addr.String())
+		require.NoError(t, err)
+		require.Equal(t, addr, parsed)
+
+		other := randomAddr(random)
+		require.Equal(t, expected.Compare(toNetip(other)), addr.Compare(other))
+	}
+}
+
+func TestAddrConversions(t *testing.T) {
+	addr := MustParseAddr("192.168.1.10")
+	assert.True(t, addr.Is4())
+	assert.Equal(t, uint32(0xc0a8010a), addr.Uint32())
+	assert.Equal(t, [4]byte{192, 168, 1, 10}, addr.As4())
+	assert.Equal(t, addr, AddrFrom4(addr.As4()))
+
+	mapped := AddrFrom16(addr.As16())
+	assert.True(t, mapped.Is6())
+	assert.True(t, mapped.Is4In6())
+	assert.Equal(t, "::ffff:192.168.1.10", mapped.String())
+	assert.Equal(t, addr, mapped.Unmap())
+	assert.NotEqual(t, addr, mapped)
+
+	assert.False(t, Addr{}.IsValid())
+	assert.Equal(t, "invalid Addr", Addr{}.String())
+	assert.Panics(t, func() { MustParseAddr("1.2.3") })
+}
+
+func TestPrefix(t *testing.T) {
+	prefix := MustParsePrefix("10.1.2.3/8")
+	assert.Equal(t, "10.1.2.3/8", prefix.String())
+	assert.Equal(t, "10.0.0.0/8", prefix.Masked().String())
+	assert.Equal(t, 8, prefix.Bits())
+
+	assert.True(t, prefix.Contains(MustParseAddr("10.255.0.1")))
+	assert.False(t, prefix.Contains(MustParseAddr("11.0.0.0")))
+	assert.False(t, prefix.Contains(MustParseAddr("::ffff:10.0.0.1")))
+
+	assert.True(t, prefix.Overlaps(MustParsePrefix("10.20.0.0/16")))
+	assert.True(t, prefix.Overlaps(MustParsePrefix("0.0.0.0/0")))
+	assert.False(t, prefix.Overlaps(MustParsePrefix("11.0.0.0/8")))
+	assert.False(t, prefix.Overlaps(MustParsePrefix("::/0")))
+
+	assert.True(t, MustParsePrefix("2001:db8::1/128").IsSingleIP())
+	assert.Equal(t, "2001:db8::/32", MustParsePrefix("2001:db8:ffff::/32").Masked().String())
+
+	assert.False(t, PrefixFrom(MustParseAddr("1.2.3.4"), 33).IsValid())
+	assert.Equal(t, "invalid Prefix", Prefix{}.String())
+
+	for _, input := range []string{"1.2.3.4", "1.2.3.4/", "1.2.3.4/33", "1.2.3.4/08", "1.2.3.4/+8", "::/129", "1.2.3/8", "/8"} {
+		_, err := ParsePrefix(input)
+		assert.ErrorIs(t, err, ErrInvalidPrefix, input)
+		_, expectedErr := netip.ParsePrefix(input)
+		assert.Error(t, expectedErr, input)
+	}
+}
+
+func TestPrefixMatchesNetip(t *testing.T) {
+	random := rand.New(rand.NewPCG(3, 4))
+	for range 10000 {
+		addr := randomAddr(random)
+		prefix := PrefixFrom(addr, random.IntN(addr.BitLen()+1))
+		expected := netip.PrefixFrom(toNetip(addr), prefix.Bits())
+
+		require.Equal(t, expected.String(), prefix.String())
+		require.Equal(t, expected.Masked().String(), prefix.Masked().String())
+
+		// nearby addresses share a long prefix
+		other := randomAddr(random)
+		if other.BitLen() == addr.BitLen() && random.IntN(2) == 0 {
+			other = PrefixFrom(addr, random.IntN(addr.BitLen()+1)).Masked().Addr()
+		}
+		require.Equal(t, expected.Contains(toNetip(other)), prefix.Contains(other), "%v %v", prefix, other)
+
+		otherPrefix := PrefixFrom(other, random.IntN(other.BitLen()+1))
+		expectedOther := netip.PrefixFrom(toNetip(other), otherPrefix.Bits())
+		require.Equal(t, expected.Overlaps(expectedOther), prefix.Overlaps(otherPrefix), "%v %v", prefix, otherPrefix)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type route struct {
+		Prefix  Prefix
+		Gateway Addr
+		Backup  Addr
+	}
+
+	data, err := json.Marshal(route{Prefix: MustParsePrefix("10.0.0.0/8"), Gateway: MustParseAddr("fe80::1")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Prefix":"10.0.0.0/8","Gateway":"fe80::1","Backup":""}`, string(data))
+
+	var decoded route
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, MustParsePrefix("10.0.0.0/8"), decoded.Prefix)
+	assert.Equal(t, MustParseAddr("fe80::1"), decoded.Gateway)
+	assert.False(t, decoded.Backup.IsValid())
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"Gateway":"1.2.3"}`), &decoded), ErrInvalidAddress)
+}
+
+func TestTable(t *testing.T) {
+	var table Table[string]
+	table.Insert(MustParsePrefix("0.0.0.0/0"), "default")
+	table.Insert(MustParsePrefix("10.0.0.0/8"), "private")
+	table.Insert(MustParsePrefix("10.1.0.0/16"), "office")
+	table.Insert(MustParsePrefix("10.1.2.0/24"), "lab")
+	table.Insert(MustParsePrefix("2001:db8::/32"), "docs")
+	assert.Equal(t, 5, table.Len())
+
+	lookup := func(address string) string {
+		prefix, value, ok := table.Lookup(MustParseAddr(address))
+		if !ok {
+			return "none"
+		}
+		return value + " " + prefix.String()
+	}
+
+	assert.Equal(t, "lab 10.1.2.0/24", lookup("10.1.2.3"))
+	assert.Equal(t, "office 10.1.0.0/16", lookup("10.1.3.1"))
+	assert.Equal(t, "private 10.0.0.0/8", lookup("10.200.0.1"))
+	assert.Equal(t, "default 0.0.0.0/0", lookup("8.8.8.8"))
+	assert.Equal(t, "docs 2001:db8::/32", lookup("2001:db8::1"))
+	assert.Equal(t, "none", lookup("2001:db9::1"))
+	// IPv4-mapped addresses are IPv6 and don't match IPv4 routes
+	assert.Equal(t, "none", lookup("::ffff:10.1.2.3"))
+
+	value, ok := table.Get(MustParsePrefix("10.1.255.255/16"))
+	assert.True(t, ok)
+	assert.Equal(t, "office", value)
+	_, ok = table.Get(MustParsePrefix("10.1.0.0/17"))
+	assert.False(t, ok)
+
+	table.Insert(MustParsePrefix("10.0.0.0/8"), "corporate")
+	assert.Equal(t, 5, table.Len())
+	assert.Equal(t, "corporate 10.0.0.0/8", lookup("10.200.0.1"))
+
+	assert.True(t, table.Delete(MustParsePrefix("10.1.0.0/16")))
+	assert.False(t, table.Delete(MustParsePrefix("10.1.0.0/16")))
+	assert.Equal(t, "corporate 10.0.0.0/8", lookup("10.1.3.1"))
+	assert.Equal(t, "lab 10.1.2.0/24", lookup("10.1.2.3"))
+
+	var prefixes []string
+	for prefix, value := range table.All() {
+		prefixes = append(prefixes, prefix.String()+" "+value)
+	}
+	assert.Equal(t, []string{"0.0.0.0/0 default", "10.0.0.0/8 corporate", "10.1.2.0/24 lab", "2001:db8::/32 docs"}, prefixes)
+}
+
+// TestTableMatchesBruteForce checks the trie against a linear scan
+// over netip prefixes while routes are added and removed.
+func TestTableMatchesBruteForce(t *testing.T) {
+	random := rand.New(rand.NewPCG(5, 6))
+
+	// a few base addresses make prefixes nest and share paths
+	bases := make([]Addr, 8)
+	for idx := range bases {
+		bases[idx] = randomAddr(random)
+	}
+	randomNear := func() Addr {
+		base := bases[random.IntN(len(bases))]
+		other := randomAddr(random)
+		for other.BitLen() != base.BitLen() {
+			other = randomAddr(random)
+		}
+		// keep a random number of leading bits of the base
+		keep := random.IntN(base.BitLen() + 1)
+		octets, otherOctets := base.As16(), other.As16()
+		offset := 128 - base.BitLen()
+		for bit := keep; bit < base.BitLen(); bit++ {
+			idx, shift := (offset+bit)/8, 7-(offset+bit)%8
+			octets[idx] = octets[idx]&^(1<<shift) | otherOctets[idx]&(1<<shift)
+		}
+		if base.Is4() {
+			return AddrFrom16(octets).Unmap()
+		}
+		return AddrFrom16(octets)
+	}
+
+	var table Table[int]
+	expected := make(map[netip.Prefix]int)
+
+	for step := range 5000 {
+		addr := randomNear()
+		prefix := PrefixFrom(addr, random.IntN(addr.BitLen()+1)).Masked()
+		key := netip.PrefixFrom(toNetip(addr), prefix.Bits()).Masked()
+
+		if random.IntN(3) == 0 {
+			_, present := expected[key]
+			require.Equal(t, present, table.Delete(prefix))
+			delete(expected, key)
+		} else {
+			table.Insert(prefix, step)
+			expected[key] = step
+		}
+		require.Equal(t, len(expected), table.Len())
+
+		for range 5 {
+			addr := randomNear()
+			target := toNetip(addr)
+
+			best, bestValue, found := netip.Prefix{}, 0, false
+			for candidate, value := range expected {
+				if candidate.Contains(target) && (!found || candidate.Bits() > best.Bits()) {
+					best, bestValue, found = candidate, value, true
+				}
+			}
+
+			prefix, value, ok := table.Lookup(addr)
+			require.Equal(t, found, ok, addr)
+			if found {
+				require.Equal(t, best.String(), prefix.String())
+				require.Equal(t, bestValue, value)
+			}
+		}
+	}
+
+	count := 0
+	for prefix, value := range table.All() {
+		key := netip.MustParsePrefix(prefix.String())
+		require.Equal(t, expected[key], value)
+		count++
+	}
+	assert.Equal(t, len(expected), count)
+}
+
+func FuzzParseAddr(f *testing.F) {
+	for _, seed := range []string{"1.2.3.4", "::", "::ffff:1.2.3.4", "2001:db8::1", "1:2:3:4:5:6:7::", "01.2.3.4", "::1.2.3"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		addr, err := ParseAddr(input)
+		expected, expectedErr := netip.ParseAddr(input)
+		if strings.Contains(input, "%") {
+			// zones are not supported
+			require.Error(t, err)
+			return
+		}
+
+		if expectedErr != nil {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), addr.String())
+		require.Equal(t, expected, toNetip(addr))
+	})
+}
+
+func FuzzParsePrefix(f *testing.F) {
+	for _, seed := range []string{"10.0.0.0/8", "10.1.2.3/32", "::/0", "2001:db8::/32", "1.2.3.4/08", "::ffff:1.2.3.4/120"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		prefix, err := ParsePrefix(input)
+		expected, expectedErr := netip.ParsePrefix(input)
+		if strings.Contains(input, "%") || expectedErr != nil {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), prefix.String())
+		require.Equal(t, expected.Masked().String(), prefix.Masked().String())
+	})
+}
+
+func BenchmarkTableLookup(b *testing.B) {
+	random := rand.New(rand.NewPCG(7, 8))
+
+	var table Table[int]
+	for idx := range 100000 {
+		addr := AddrFromUint32(random.Uint32())
+		table.Insert(PrefixFrom(addr, 8+random.IntN(25)), idx)
+	}
+
+	addrs := make([]Addr, 1024)
+	for idx := range addrs {
+		addrs[idx] = AddrFromUint32(random.Uint32())
+	}
+
+	b.ResetTimer()
+	for idx := 0; idx < b.N; idx++ {
+		table.Lookup(addrs[idx%len(addrs)])
+	}
+}
@@ -0,0 +1,138 @@
+package ipaddr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPrefix = errors.New("ipaddr: invalid prefix")
+
+// Prefix is a CIDR block like 10.0.0.0/8 or 2001:db8::/32.
+// Host bits after the prefix are kept, Masked clears them.
+type Prefix struct {
+	addr Addr
+	bits int
+}
+
+// PrefixFrom returns an invalid Prefix if bits doesn't fit the address.
+func PrefixFrom(addr Addr, bits int) Prefix {
+	if !addr.IsValid() || bits < 0 || bits > addr.BitLen() {
+		return Prefix{}
+	}
+	return Prefix{addr: addr, bits: bits}
+}
+
+func ParsePrefix(s string) (Prefix, error) {
+	idx := strings.LastIndexByte(s, '/')
+	if idx < 0 {
+		return Prefix{}, prefixError(s, "no '/'")
+	}
+
+	addr, err := ParseAddr(s[:idx])
+	if err != nil {
+		return Prefix{}, fmt.Errorf("%w %q: %w", ErrInvalidPrefix, s, err)
+	}
+
+	length := s[idx+1:]
+	if length == "" || len(length) > 1 && length[0] == '0' {
+		return Prefix{}, prefixError(s, "bad prefix length")
+	}
+	for idx := 0; idx < len(length); idx++ {
+		if length[idx] < '0' || length[idx] > '9' {
+			return Prefix{}, prefixError(s, "bad prefix length")
+		}
+	}
+
+	bits, err := strconv.Atoi(length)
+	if err != nil || bits > addr.BitLen() {
+		return Prefix{}, prefixError(s, "prefix length out of range")
+	}
+	return Prefix{addr: addr, bits: bits}, nil
+}
+
+func MustParsePrefix(s string) Prefix {
+	prefix, err := ParsePrefix(s)
+	if err != nil {
+		panic(err)
+	}
+	return prefix
+}
+
+func prefixError(s, reason string) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidPrefix, s, reason)
+}
+
+func (p Prefix) IsValid() bool {
+	return p.addr.IsValid()
+}
+
+func (p Prefix) Addr() Addr {
+	return p.addr
+}
+
+func (p Prefix) Bits() int {
+	return p.bits
+}
+
+// IsSingleIP reports whether the prefix covers exactly one address.
+func (p Prefix) IsSingleIP() bool {
+	return p.IsValid() && p.bits == p.addr.BitLen()
+}
+
+// Masked returns the prefix with the host bits set to zero.
+func (p Prefix) Masked() Prefix {
+	if !p.IsValid() {
+		return Prefix{}
+	}
+	return Prefix{addr: p.addr.mask(p.bits), bits: p.bits}
+}
+
+// Contains reports whether the address is inside the prefix.
+// Addresses of the other family are never contained, so
+// 1.2.3.4 is not inside ::ffff:0:0/96.
+func (p Prefix) Contains(addr Addr) bool {
+	if !p.IsValid() || addr.bitLen != p.addr.bitLen {
+		return false
+	}
+	return addr.commonBits(p.addr) >= p.bits
+}
+
+// Overlaps reports whether the prefixes share any address,
+// that is whether one of them contains the other.
+func (p Prefix) Overlaps(other Prefix) bool {
+	if !p.IsValid() || !other.IsValid() || p.addr.bitLen != other.addr.bitLen {
+		return false
+	}
+	return p.addr.commonBits(other.addr) >= min(p.bits, other.bits)
+}
+
+func (p Prefix) String() string {
+	if !p.IsValid() {
+		return "invalid Prefix"
+	}
+	return p.addr.String() + "/" + strconv.Itoa(p.bits)
+}
+
+func (p Prefix) MarshalText() ([]byte, error) {
+	if !p.IsValid() {
+		return []byte{}, nil
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText turns an empty text into the zero Prefix.
+func (p *Prefix) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = Prefix{}
+		return nil
+	}
+
+	prefix, err := ParsePrefix(string(text))
+	if err != nil {
+		return err
+	}
+	*p = prefix
+	return nil
+}
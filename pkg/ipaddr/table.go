@@ -0,0 +1,198 @@
+package ipaddr
+
+import "iter"
+
+// Table maps prefixes to values and finds the longest prefix
+// containing an address, like a routing table does.
+//
+// It is a path-compressed binary trie (Patricia trie), one per
+// address family: every node stores a masked prefix, and a node
+// without a value exists only to join two subtrees, so the depth is
+// bounded by the address length and the size by twice the prefix count.
+type Table[V any] struct {
+	root4 *node[V]
+	root6 *node[V]
+	size  int
+}
+
+type node[V any] struct {
+	prefix   Prefix
+	value    V
+	hasValue bool
+	children [2]*node[V]
+}
+
+func (t *Table[V]) Len() int {
+	return t.size
+}
+
+func (t *Table[V]) root(addr Addr) **node[V] {
+	if addr.Is4() {
+		return &t.root4
+	}
+	return &t.root6
+}
+
+// Insert adds the prefix or replaces its value. Host bits are ignored,
+// so 10.1.2.3/8 is the same entry as 10.0.0.0/8.
+func (t *Table[V]) Insert(prefix Prefix, value V) {
+	if !prefix.IsValid() {
+		return
+	}
+	prefix = prefix.Masked()
+
+	link := t.root(prefix.addr)
+	for {
+		current := *link
+		if current == nil {
+			*link = &node[V]{prefix: prefix, value: value, hasValue: true}
+			t.size++
+			return
+		}
+
+		common := min(current.prefix.addr.commonBits(prefix.addr), current.prefix.bits, prefix.bits)
+		switch {
+		case common == current.prefix.bits && common == prefix.bits:
+			if !current.hasValue {
+				t.size++
+			}
+			current.value, current.hasValue = value, true
+			return
+		case common == current.prefix.bits:
+			// the new prefix is below the current node
+			link = &current.children[prefix.addr.bit(common)]
+		case common == prefix.bits:
+			// the current node is below the new prefix
+			inserted := &node[V]{prefix: prefix, value: value, hasValue: true}
+			inserted.children[current.prefix.addr.bit(common)] = current
+			*link = inserted
+			t.size++
+			return
+		default:
+			// the prefixes diverge, join them with a node without value
+			leaf := &node[V]{prefix: prefix, value: value, hasValue: true}
+			join := &node[V]{prefix: Prefix{addr: prefix.addr.mask(common), bits: common}}
+			join.children[prefix.addr.bit(common)] = leaf
+			join.children[current.prefix.addr.bit(common)] = current
+			*link = join
+			t.size++
+			return
+		}
+	}
+}
+
+// Get returns the value stored for exactly this prefix.
+func (t *Table[V]) Get(prefix Prefix) (V, bool) {
+	var zero V
+	if !prefix.IsValid() {
+		return zero, false
+	}
+	prefix = prefix.Masked()
+
+	current := *t.root(prefix.addr)
+	for current != nil && current.prefix.bits <= prefix.bits && current.prefix.Contains(prefix.addr) {
+		if current.prefix.bits == prefix.bits {
+			if current.hasValue {
+				return current.value, true
+			}
+			break
+		}
+		current = current.children[prefix.addr.bit(current.prefix.bits)]
+	}
+	return zero, false
+}
+
+// Lookup returns the longest prefix containing the address and its value.
+func (t *Table[V]) Lookup(addr Addr) (Prefix, V, bool) {
+	var found *node[V]
+	if addr.IsValid() {
+		current := *t.root(addr)
+		for current != nil && current.prefix.Contains(addr) {
+			if current.hasValue {
+				found = current
+			}
+			if current.prefix.bits == addr.BitLen() {
+				break
+			}
+			current = current.children[addr.bit(current.prefix.bits)]
+		}
+	}
+
+	if found == nil {
+		var zero V
+		return Prefix{}, zero, false
+	}
+	return found.prefix, found.value, true
+}
+
+// Delete removes the prefix and reports whether it was present.
+func (t *Table[V]) Delete(prefix Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+	prefix = prefix.Masked()
+
+	link := t.root(prefix.addr)
+	root, deleted := remove(*link, prefix)
+	*link = root
+	if deleted {
+		t.size--
+	}
+	return deleted
+}
+
+// remove returns the new root of the subtree.
+func remove[V any](current *node[V], prefix Prefix) (*node[V], bool) {
+	if current == nil || current.prefix.bits > prefix.bits || !current.prefix.Contains(prefix.addr) {
+		return current, false
+	}
+
+	if current.prefix.bits == prefix.bits {
+		if !current.hasValue {
+			return current, false
+		}
+		var zero V
+		current.value, current.hasValue = zero, false
+		return compact(current), true
+	}
+
+	bit := prefix.addr.bit(current.prefix.bits)
+	child, deleted := remove(current.children[bit], prefix)
+	current.children[bit] = child
+	if !deleted {
+		return current, false
+	}
+	return compact(current), true
+}
+
+// compact drops a node without value that doesn't join two subtrees.
+func compact[V any](current *node[V]) *node[V] {
+	switch {
+	case current.hasValue:
+		return current
+	case current.children[0] == nil:
+		return current.children[1]
+	case current.children[1] == nil:
+		return current.children[0]
+	default:
+		return current
+	}
+}
+
+// All iterates over the prefixes in order, IPv4 first,
+// a prefix always coming before the more specific ones.
+func (t *Table[V]) All() iter.Seq2[Prefix, V] {
+	return func(yield func(Prefix, V) bool) {
+		_ = walk(t.root4, yield) && walk(t.root6, yield)
+	}
+}
+
+func walk[V any](current *node[V], yield func(Prefix, V) bool) bool {
+	if current == nil {
+		return true
+	}
+	if current.hasValue && !yield(current.prefix, current.value) {
+		return false
+	}
+	return walk(current.children[0], yield) && walk(current.children[1], yield)
+}
@@ -0,0 +1,127 @@
+package leakcheck
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Goroutine is a parsed entry of runtime.Stack(all=true) output.
+type Goroutine struct {
+	ID    int
+	State string
+	// TopFunction is the function the goroutine is currently in.
+	TopFunction string
+	// CreatedBy is the function which started the goroutine,
+	// empty for the main goroutine.
+	CreatedBy string
+	// Stack is the full trace including the "created by" frame.
+	Stack string
+}
+
+func (g Goroutine) String() string {
+	return g.Stack
+}
+
+func (g Goroutine) hasFunction(name string) bool {
+	if g.CreatedBy == name {
+		return true
+	}
+
+	for _, line := range strings.Split(g.Stack, "\n")[1:] {
+		if !strings.HasPrefix(line, "\t") && functionName(line) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stacks() []byte {
+	buffer := make([]byte, 64*1024)
+	for {
+		size := runtime.Stack(buffer, true)
+		if size < len(buffer) {
+			return buffer[:size]
+		}
+		buffer = make([]byte, 2*len(buffer))
+	}
+}
+
+func currentID() int {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+	goroutine, _ := parseHeader(string(buffer))
+	return goroutine.ID
+}
+
+// All returns all goroutines except the one calling it.
+func All() []Goroutine {
+	current := currentID()
+
+	var goroutines []Goroutine
+	for _, dump := range strings.Split(string(stacks()), "\n\n") {
+		goroutine, ok := parse(dump)
+		if ok && goroutine.ID != current {
+			goroutines = append(goroutines, goroutine)
+		}
+	}
+
+	return goroutines
+}
+
+func parse(dump string) (Goroutine, bool) {
+	dump = strings.TrimSpace(dump)
+	goroutine, ok := parseHeader(dump)
+	if !ok {
+		return Goroutine{}, false
+	}
+
+	goroutine.Stack = dump
+
+	lines := strings.Split(dump, "\n")
+	if len(lines) > 1 {
+		goroutine.TopFunction = functionName(lines[1])
+	}
+
+	for _, line := range lines {
+		if createdBy, ok := strings.CutPrefix(line, "created by "); ok {
+			// since go1.21 the line ends with "in goroutine N"
+			createdBy, _, _ = strings.Cut(createdBy, " in goroutine ")
+			goroutine.CreatedBy = createdBy
+		}
+	}
+
+	return goroutine, true
+}
+
+// parseHeader parses lines like "goroutine 7 [chan receive, 2 minutes]:"
+func parseHeader(dump string) (Goroutine, bool) {
+	header, _, _ := strings.Cut(dump, "\n")
+	header, ok := strings.CutPrefix(header, "goroutine ")
+	if !ok {
+		return Goroutine{}, false
+	}
+
+	id, state, ok := strings.Cut(header, " [")
+	if !ok {
+		return Goroutine{}, false
+	}
+
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return Goroutine{}, false
+	}
+
+	state = strings.TrimSuffix(state, "]:")
+	state, _, _ = strings.Cut(state, ",")
+	return Goroutine{ID: number, State: state}, true
+}
+
+// functionName strips arguments from a frame like "main.worker(0xc000012345, 0x1)"
+func functionName(line string) string {
+	if idx := strings.LastIndex(line, "("); idx > 0 {
+		return line[:idx]
+	}
+	return line
+}
@@ -0,0 +1,143 @@
+package leakcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type config struct {
+	filters []func(Goroutine) bool
+	maxWait time.Duration
+}
+
+type Option func(*config)
+
+// IgnoreTopFunction ignores goroutines which are currently in the
+// given function, e.g. "internal/poll.runtime_pollWait".
+func IgnoreTopFunction(name string) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, func(g Goroutine) bool {
+			return g.TopFunction == name
+		})
+	}
+}
+
+// IgnoreAnyFunction ignores goroutines having the function anywhere in the stack.
+func IgnoreAnyFunction(name string) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, func(g Goroutine) bool {
+			return g.hasFunction(name)
+		})
+	}
+}
+
+func IgnoreCreatedBy(name string) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, func(g Goroutine) bool {
+			return g.CreatedBy == name
+		})
+	}
+}
+
+// MaxWait limits how long goroutines are given to finish, 1 second by default.
+func MaxWait(duration time.Duration) Option {
+	return func(c *config) {
+		c.maxWait = duration
+	}
+}
+
+var defaultFilters = []func(Goroutine) bool{
+	// goroutines of other tests and of the test runner itself
+	func(g Goroutine) bool { return g.hasFunction("testing.tRunner") },
+	func(g Goroutine) bool { return g.hasFunction("testing.(*M).Run") },
+	func(g Goroutine) bool { return g.TopFunction == "testing.(*T).Run" },
+	func(g Goroutine) bool { return g.hasFunction("os/signal.signal_recv") },
+	func(g Goroutine) bool { return g.hasFunction("os/signal.loop") },
+}
+
+// Snapshot remembers the goroutines alive at some moment,
+// Find reports only the goroutines started after it.
+type Snapshot struct {
+	ids map[int]bool
+}
+
+func Take() Snapshot {
+	snapshot := Snapshot{ids: make(map[int]bool)}
+	for _, goroutine := range All() {
+		snapshot.ids[goroutine.ID] = true
+	}
+	return snapshot
+}
+
+// Find waits with exponential backoff until all goroutines started
+// after the snapshot have exited and returns those that are still alive.
+func (s Snapshot) Find(options ...Option) []Goroutine {
+	c := config{maxWait: time.Second}
+	for _, option := range options {
+		option(&c)
+	}
+
+	deadline := time.Now().Add(c.maxWait)
+	delay := time.Microsecond
+	for {
+		leaked := s.leaked(c.filters)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+
+		time.Sleep(delay)
+		delay = min(2*delay, 100*time.Millisecond, time.Until(deadline))
+	}
+}
+
+func (s Snapshot) leaked(filters []func(Goroutine) bool) []Goroutine {
+	var leaked []Goroutine
+	for _, goroutine := range All() {
+		if !s.ids[goroutine.ID] && !ignored(goroutine, filters) {
+			leaked = append(leaked, goroutine)
+		}
+	}
+	return leaked
+}
+
+func ignored(goroutine Goroutine, filters []func(Goroutine) bool) bool {
+	for _, filter := range defaultFilters {
+		if filter(goroutine) {
+			return true
+		}
+	}
+
+	for _, filter := range filters {
+		if filter(goroutine) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify takes a snapshot and fails the test if goroutines started
+// after it are still running when the test and its cleanups finish.
+// Call it first in the test, so its check runs after other cleanups.
+func Verify(t testing.TB, options ...Option) {
+	t.Helper()
+
+	snapshot := Take()
+	t.Cleanup(func() {
+		if leaked := snapshot.Find(options...); len(leaked) != 0 {
+			t.Error(Report(leaked))
+		}
+	})
+}
+
+func Report(leaked []Goroutine) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "found %d leaked goroutines:", len(leaked))
+	for _, goroutine := range leaked {
+		builder.WriteString("\n\n")
+		builder.WriteString(goroutine.Stack)
+	}
+	return builder.String()
+}
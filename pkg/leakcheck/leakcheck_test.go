@@ -0,0 +1,127 @@
+package leakcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blockForever(started, ch chan struct{}) {
+	close(started)
+	<-ch
+}
+
+func startBlocked(ch chan struct{}) {
+	started := make(chan struct{})
+	go blockForever(started, ch)
+	<-started
+}
+
+func TestFindLeakedGoroutine(t *testing.T) {
+	snapshot := Take()
+
+	ch := make(chan struct{})
+	defer close(ch)
+	startBlocked(ch)
+
+	leaked := snapshot.Find(MaxWait(50 * time.Millisecond))
+	require.Len(t, leaked, 1)
+	assert.Equal(t, "chan receive", leaked[0].State)
+	assert.Equal(t, "golang_course/pkg/leakcheck.blockForever", leaked[0].TopFunction)
+	assert.Equal(t, "golang_course/pkg/leakcheck.startBlocked", leaked[0].CreatedBy)
+	assert.Contains(t, Report(leaked), "found 1 leaked goroutines")
+	assert.Contains(t, Report(leaked), "leakcheck_test.go")
+}
+
+func TestFindWaitsForExitingGoroutines(t *testing.T) {
+	snapshot := Take()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+	}()
+
+	assert.Empty(t, snapshot.Find())
+}
+
+func TestFindIgnoresGoroutinesFromSnapshot(t *testing.T) {
+	ch := make(chan struct{})
+	defer close(ch)
+	startBlocked(ch)
+
+	snapshot := Take()
+	assert.Empty(t, snapshot.Find(MaxWait(0)))
+}
+
+func TestFindWithFilters(t *testing.T) {
+	snapshot := Take()
+
+	ch := make(chan struct{})
+	defer close(ch)
+	startBlocked(ch)
+
+	filters := []Option{
+		IgnoreTopFunction("golang_course/pkg/leakcheck.blockForever"),
+		IgnoreAnyFunction("golang_course/pkg/leakcheck.blockForever"),
+		IgnoreCreatedBy("golang_course/pkg/leakcheck.startBlocked"),
+	}
+
+	for _, filter := range filters {
+		assert.Empty(t, snapshot.Find(MaxWait(0), filter))
+	}
+
+	assert.Len(t, snapshot.Find(MaxWait(0), IgnoreTopFunction("main.other")), 1)
+}
+
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Error(args ...any) {
+	for _, arg := range args {
+		r.errors = append(r.errors, arg.(string))
+	}
+}
+
+func (r *recordingTB) Cleanup(cleanup func()) {
+	r.cleanups = append(r.cleanups, cleanup)
+}
+
+func TestVerify(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	Verify(tb, MaxWait(10*time.Millisecond))
+
+	ch := make(chan struct{})
+	startBlocked(ch)
+
+	require.Len(t, tb.cleanups, 1)
+	tb.cleanups[0]()
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "blockForever")
+
+	close(ch)
+}
+
+func TestVerifyWithoutLeaks(t *testing.T) {
+	Verify(t)
+
+	done := make(chan struct{})
+	go func() {
+		close(done)
+	}()
+	<-done
+}
+
+func TestVerifyWithSubtests(t *testing.T) {
+	Verify(t)
+
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+		time.Sleep(10 * time.Millisecond)
+	})
+}
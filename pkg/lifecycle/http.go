@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+)
+
+type httpServer struct {
+	server *http.Server
+}
+
+// HTTPServer adapts http.Server to Component. Start returns as soon
+// as the listener is open, Stop calls Shutdown to finish active requests.
+func HTTPServer(server *http.Server) Component {
+	return &httpServer{server: server}
+}
+
+func (s *httpServer) Start(ctx context.Context) error {
+	address := s.server.Addr
+	if address == "" {
+		address = ":http"
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("lifecycle: http server %s: %v", address, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *httpServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,180 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DefaultStopTimeout = 5 * time.Second
+
+type Component interface {
+	// Start must not block: long running work is started in
+	// background and Start returns once the component is ready.
+	Start(ctx context.Context) error
+	// Stop should return when ctx expires even if it isn't done.
+	Stop(ctx context.Context) error
+}
+
+// Hooks adapts a pair of functions to Component, nil hooks do nothing.
+type Hooks struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+func (h Hooks) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+func (h Hooks) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// ComponentError tells which component failed and at which stage.
+type ComponentError struct {
+	Name  string
+	Stage string
+	Err   error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Stage, e.Name, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+type Option func(*entry)
+
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(e *entry) {
+		e.stopTimeout = timeout
+	}
+}
+
+type entry struct {
+	name        string
+	component   Component
+	stopTimeout time.Duration
+}
+
+// Manager starts components in the order of registration and stops
+// them in the reverse order, like deferred calls.
+type Manager struct {
+	mutex      sync.Mutex
+	components []entry
+	started    int
+	signals    []os.Signal
+}
+
+func New() *Manager {
+	return &Manager{signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM}}
+}
+
+// SetSignals replaces the signals Run waits for.
+func (m *Manager) SetSignals(signals ...os.Signal) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.signals = signals
+}
+
+func (m *Manager) Register(name string, component Component, options ...Option) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e := entry{name: name, component: component, stopTimeout: DefaultStopTimeout}
+	for _, option := range options {
+		option(&e)
+	}
+
+	m.components = append(m.components, e)
+}
+
+// Start starts the components one by one. If one of them fails,
+// the already started ones are stopped and all errors are returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mutex.Lock()
+	components := m.components[m.started:]
+	m.mutex.Unlock()
+
+	for _, e := range components {
+		if err := e.component.Start(ctx); err != nil {
+			startErr := &ComponentError{Name: e.name, Stage: "start", Err: err}
+			return errors.Join(startErr, m.Stop(context.WithoutCancel(ctx)))
+		}
+
+		m.mutex.Lock()
+		m.started++
+		m.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// Stop stops the started components in reverse order. Every component
+// gets its own timeout; a component which doesn't stop in time is left
+// behind and reported, so the others still get a chance to stop.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mutex.Lock()
+	components := m.components[:m.started]
+	m.started = 0
+	m.mutex.Unlock()
+
+	var errs []error
+	for idx := len(components) - 1; idx >= 0; idx-- {
+		if err := stop(ctx, components[idx]); err != nil {
+			errs = append(errs, &ComponentError{Name: components[idx].name, Stage: "stop", Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run starts all components, waits until ctx is done or one
+// of the signals is received and then stops the components.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mutex.Lock()
+	signals := m.signals
+	m.mutex.Unlock()
+
+	runCtx, cancel := signal.NotifyContext(ctx, signals...)
+	defer cancel()
+
+	if err := m.Start(runCtx); err != nil {
+		return err
+	}
+
+	<-runCtx.Done()
+
+	// the stop context must not be already cancelled as runCtx is
+	return m.Stop(context.WithoutCancel(ctx))
+}
+
+func stop(ctx context.Context, e entry) error {
+	ctx, cancel := context.WithTimeout(ctx, e.stopTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.component.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
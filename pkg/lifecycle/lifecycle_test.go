@@ -0,0 +1,206 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *recorder) component(name string, startErr, stopErr error) Component {
+	return Hooks{
+		OnStart: func(ctx context.Context) error {
+			r.add("start " + name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("stop context without deadline")
+			}
+			if ctx.Err() != nil {
+				return errors.New("stop context is already done")
+			}
+			r.add("stop " + name)
+			return stopErr
+		},
+	}
+}
+
+func TestManagerStopsInReverseOrder(t *testing.T) {
+	var events recorder
+	manager := New()
+	manager.Register("database", events.component("database", nil, nil))
+	manager.Register("cache", events.component("cache", nil, nil))
+	manager.Register("server", events.component("server", nil, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(events.list()) == 3
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{
+		"start database", "start cache", "start server",
+		"stop server", "stop cache", "stop database",
+	}, events.list())
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	var events recorder
+	failure := errors.New("connection refused")
+
+	manager := New()
+	manager.Register("database", events.component("database", nil, nil))
+	manager.Register("cache", events.component("cache", failure, nil))
+	manager.Register("server", events.component("server", nil, nil))
+
+	err := manager.Run(context.Background())
+	assert.ErrorIs(t, err, failure)
+
+	var componentErr *ComponentError
+	require.ErrorAs(t, err, &componentErr)
+	assert.Equal(t, "cache", componentErr.Name)
+	assert.Equal(t, "start", componentErr.Stage)
+
+	assert.Equal(t, []string{"start database", "start cache", "stop database"}, events.list())
+}
+
+func TestManagerCollectsStopErrors(t *testing.T) {
+	var events recorder
+	databaseErr := errors.New("database error")
+	serverErr := errors.New("server error")
+
+	manager := New()
+	manager.Register("database", events.component("database", nil, databaseErr))
+	manager.Register("cache", events.component("cache", nil, nil))
+	manager.Register("server", events.component("server", nil, serverErr))
+
+	require.NoError(t, manager.Start(context.Background()))
+	err := manager.Stop(context.Background())
+
+	assert.ErrorIs(t, err, databaseErr)
+	assert.ErrorIs(t, err, serverErr)
+	assert.EqualError(t, err, "stop server: server error\nstop database: database error")
+	assert.Equal(t, []string{"stop server", "stop cache", "stop database"}, events.list()[3:])
+}
+
+func TestManagerStopTimeout(t *testing.T) {
+	var events recorder
+
+	manager := New()
+	manager.Register("database", events.component("database", nil, nil))
+	manager.Register("stuck", Hooks{
+		OnStop: func(ctx context.Context) error {
+			// ignores ctx
+			time.Sleep(time.Second)
+			return nil
+		},
+	}, WithStopTimeout(20*time.Millisecond))
+
+	require.NoError(t, manager.Start(context.Background()))
+
+	start := time.Now()
+	err := manager.Stop(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stop stuck")
+	assert.Contains(t, events.list(), "stop database")
+}
+
+func TestManagerStopsOnSignal(t *testing.T) {
+	var events recorder
+	manager := New()
+	manager.SetSignals(syscall.SIGUSR1)
+	manager.Register("worker", events.component("worker", nil, nil))
+
+	done := make(chan error)
+	go func() {
+		done <- manager.Run(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(events.list()) == 1
+	}, time.Second, time.Millisecond)
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("manager didn't stop on signal")
+	}
+
+	assert.Equal(t, []string{"start worker", "stop worker"}, events.list())
+}
+
+func TestHTTPServer(t *testing.T) {
+	released := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		<-released
+		_, _ = io.WriteString(w, "hello world\n")
+	})
+
+	// a free port is picked by the test, so it knows the address
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	server := &http.Server{Addr: address, Handler: mux}
+	component := HTTPServer(server)
+	require.NoError(t, component.Start(context.Background()))
+
+	responses := make(chan string)
+	go func() {
+		response, err := http.Get("http://" + address)
+		if !assert.NoError(t, err) {
+			responses <- ""
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responses <- string(body)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(released)
+	}()
+
+	// the active request is finished before the server stops
+	require.NoError(t, component.Stop(context.Background()))
+	assert.Equal(t, "hello world\n", <-responses)
+}
@@ -0,0 +1,223 @@
+package lockcopy
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `check for locks passed or copied by value
+
+The lockcopy analyzer reports value receivers, by-value parameters,
+assignments, composite literal fields, call arguments, return values
+and range variables which copy a struct containing one of the lock types.
+By default these are sync.Mutex, sync.RWMutex, sync.WaitGroup, the
+homework RWMutex and syncx.NoCopy, more can be added with -types.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "lockcopy",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var DefaultLockTypes = []string{
+	"sync.Mutex",
+	"sync.RWMutex",
+	"sync.WaitGroup",
+	"golang_course/homework/sync_primitives.RWMutex",
+	"golang_course/pkg/syncx.NoCopy",
+}
+
+var extraLockTypes string
+
+func init() {
+	Analyzer.Flags.StringVar(&extraLockTypes, "types", "",
+		"comma-separated list of additional lock types in the form import/path.Name")
+}
+
+type checker struct {
+	pass  *analysis.Pass
+	locks map[string]bool
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	c := &checker{pass: pass, locks: make(map[string]bool)}
+	for _, name := range DefaultLockTypes {
+		c.locks[name] = true
+	}
+	for _, name := range strings.Split(extraLockTypes, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.locks[name] = true
+		}
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.RangeStmt)(nil),
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			c.checkFuncDecl(node)
+		case *ast.FuncLit:
+			c.checkParams(node.Type)
+		case *ast.AssignStmt:
+			for idx, expr := range node.Rhs {
+				if len(node.Lhs) == len(node.Rhs) && isBlank(node.Lhs[idx]) {
+					continue
+				}
+				c.checkCopy(expr, "assignment copies lock value")
+			}
+		case *ast.ValueSpec:
+			for _, expr := range node.Values {
+				c.checkCopy(expr, "variable declaration copies lock value")
+			}
+		case *ast.CompositeLit:
+			for _, expr := range node.Elts {
+				if kv, ok := expr.(*ast.KeyValueExpr); ok {
+					expr = kv.Value
+				}
+				c.checkCopy(expr, "literal copies lock value")
+			}
+		case *ast.CallExpr:
+			c.checkCall(node)
+		case *ast.ReturnStmt:
+			for _, expr := range node.Results {
+				c.checkCopy(expr, "return copies lock value")
+			}
+		case *ast.RangeStmt:
+			c.checkRange(node)
+		}
+	})
+
+	return nil, nil
+}
+
+func (c *checker) checkFuncDecl(decl *ast.FuncDecl) {
+	if decl.Recv != nil && len(decl.Recv.List) != 0 {
+		field := decl.Recv.List[0]
+		if path := c.lockPath(c.pass.TypesInfo.TypeOf(field.Type)); path != "" {
+			c.pass.Reportf(field.Pos(), "%s has value receiver which copies lock: %s", decl.Name.Name, path)
+		}
+	}
+
+	c.checkParams(decl.Type)
+}
+
+func (c *checker) checkParams(funcType *ast.FuncType) {
+	for _, field := range funcType.Params.List {
+		if path := c.lockPath(c.pass.TypesInfo.TypeOf(field.Type)); path != "" {
+			c.pass.Reportf(field.Pos(), "parameter passes lock by value: %s", path)
+		}
+	}
+}
+
+func (c *checker) checkCall(call *ast.CallExpr) {
+	if tv, ok := c.pass.TypesInfo.Types[call.Fun]; ok && tv.IsBuiltin() {
+		return
+	}
+
+	for _, expr := range call.Args {
+		c.checkCopy(expr, "call passes lock by value")
+	}
+}
+
+func (c *checker) checkRange(stmt *ast.RangeStmt) {
+	if stmt.Value == nil {
+		return
+	}
+
+	if isBlank(stmt.Value) {
+		return
+	}
+
+	if path := c.lockPath(c.pass.TypesInfo.TypeOf(stmt.Value)); path != "" {
+		c.pass.Reportf(stmt.Value.Pos(), "range variable copies lock: %s", path)
+	}
+}
+
+func (c *checker) checkCopy(expr ast.Expr, message string) {
+	expr = ast.Unparen(expr)
+	switch expr := expr.(type) {
+	case *ast.CompositeLit, *ast.FuncLit:
+		// a new value is created, nothing is copied
+		return
+	case *ast.CallExpr:
+		if tv, ok := c.pass.TypesInfo.Types[expr.Fun]; !ok || !tv.IsType() {
+			// results of calls are fresh values, conversions are copies
+			return
+		}
+	}
+
+	tv, ok := c.pass.TypesInfo.Types[expr]
+	if !ok || tv.IsType() {
+		return
+	}
+
+	if path := c.lockPath(tv.Type); path != "" {
+		c.pass.Reportf(expr.Pos(), "%s: %s", message, path)
+	}
+}
+
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// lockPath returns a description like "main.Stack contains sync.Mutex"
+// when a value of typ holds a lock, or an empty string otherwise.
+func (c *checker) lockPath(typ types.Type) string {
+	if typ == nil {
+		return ""
+	}
+
+	path := c.findLock(typ, make(map[types.Type]bool))
+	if len(path) == 0 {
+		return ""
+	}
+
+	return strings.Join(path, " contains ")
+}
+
+func (c *checker) findLock(typ types.Type, seen map[types.Type]bool) []string {
+	if seen[typ] {
+		return nil
+	}
+	seen[typ] = true
+
+	name := types.TypeString(typ, types.RelativeTo(c.pass.Pkg))
+	if named, ok := types.Unalias(typ).(*types.Named); ok {
+		obj := named.Origin().Obj()
+		if obj.Pkg() != nil && c.locks[obj.Pkg().Path()+"."+obj.Name()] {
+			return []string{name}
+		}
+	}
+
+	switch underlying := typ.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < underlying.NumFields(); i++ {
+			if path := c.findLock(underlying.Field(i).Type(), seen); path != nil {
+				return append([]string{name}, path...)
+			}
+		}
+	case *types.Array:
+		if path := c.findLock(underlying.Elem(), seen); path != nil {
+			return append([]string{name}, path...)
+		}
+	}
+
+	return nil
+}
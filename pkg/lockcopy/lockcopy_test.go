@@ -0,0 +1,17 @@
+package lockcopy
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer,
+		"golang_course/lessons/sync_primitives/wait_group_copying",
+		"golang_course/lessons/sync_primitives/lockable_struct",
+		"golang_course/lessons/sync_primitives/sync_stack",
+		"golang_course/homework/sync_primitives",
+		"golang_course/copies",
+	)
+}
@@ -0,0 +1,57 @@
+package copies
+
+import (
+	"sync"
+
+	"golang_course/pkg/syncx"
+)
+
+type Counter struct {
+	mutex sync.Mutex
+	value int
+}
+
+type Buffer struct {
+	_    syncx.NoCopy
+	data []byte
+}
+
+type Shards struct {
+	counters [4]Counter
+}
+
+func NewCounter() Counter {
+	return Counter{}
+}
+
+func copies(counter *Counter, counters []Counter, buffer Buffer) { // want `parameter passes lock by value: Buffer contains golang_course/pkg/syncx.NoCopy`
+	local := *counter // want `assignment copies lock value: Counter contains sync.Mutex`
+	var other = local // want `variable declaration copies lock value: Counter contains sync.Mutex`
+	other = NewCounter()
+	_ = other
+
+	shards := Shards{}
+	shardsCopy := shards // want `assignment copies lock value: Shards contains \[4\]Counter contains Counter contains sync.Mutex`
+	_ = shardsCopy
+
+	_ = []Counter{local} // want `literal copies lock value: Counter contains sync.Mutex`
+
+	for _, counter := range counters { // want `range variable copies lock: Counter contains sync.Mutex`
+		_ = counter.value
+	}
+
+	for idx := range counters {
+		counters[idx].mutex.Lock()
+		counters[idx].value++
+		counters[idx].mutex.Unlock()
+	}
+
+	pointer := &local
+	_ = pointer
+
+	go func(mutex sync.RWMutex) {}(sync.RWMutex{}) // want `parameter passes lock by value: sync.RWMutex`
+}
+
+func current(counters []Counter) Counter {
+	return counters[0] // want `return copies lock value: Counter contains sync.Mutex`
+}
@@ -0,0 +1,14 @@
+package main
+
+import "sync"
+
+func done(wg sync.WaitGroup) { // want `parameter passes lock by value: sync.WaitGroup`
+	wg.Done()
+}
+
+func main() {
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	done(wg) // want `call passes lock by value: sync.WaitGroup`
+	wg.Wait()
+}
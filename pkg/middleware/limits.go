@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang_course/pkg/panics"
+)
+
+// Timeout runs the handler with a deadline on the request context and
+// buffers its response. If the deadline fires first, 503 is sent right
+// away, even if the handler ignores ctx, and its later writes fail with
+// http.ErrHandlerTimeout. Like http.TimeoutHandler, it doesn't support
+// Flush and Hijack. A panic in the handler is raised again in the caller,
+// or written to logger if it happens after the deadline.
+func Timeout(timeout time.Duration, logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan error, 1)
+			go func() {
+				done <- panics.SafeCall(func() error {
+					next.ServeHTTP(tw, r.WithContext(ctx))
+					return nil
+				})
+			}()
+
+			select {
+			case err := <-done:
+				var panicErr *panics.PanicError
+				if errors.As(err, &panicErr) {
+					panicErr.Repanic()
+				}
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.mutex.Lock()
+				tw.timedOut = true
+				tw.mutex.Unlock()
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+				// nobody can recover the panic of the abandoned handler
+				go func() {
+					var panicErr *panics.PanicError
+					if errors.As(<-done, &panicErr) && panicErr.Value != http.ErrAbortHandler {
+						traceID, _ := TraceIDFrom(r.Context())
+						logger.Printf("panic after timeout serving %s %s trace_id=%s: %v\n%s",
+							r.Method, r.URL.Path, traceID, panicErr.Value, panicErr.Stack)
+					}
+				}()
+			}
+		})
+	}
+}
+
+// timeoutWriter keeps the response in memory until the handler returns.
+type timeoutWriter struct {
+	header http.Header
+
+	mutex    sync.Mutex
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.status == 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) flushTo(dst http.ResponseWriter) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	maps.Copy(dst.Header(), w.header)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	dst.WriteHeader(w.status)
+	_, _ = dst.Write(w.body.Bytes())
+}
+
+// MaxBodySize rejects requests with a declared body larger than limit
+// and makes reading past the limit fail for the others.
+func MaxBodySize(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"golang_course/pkg/panics"
+)
+
+// Logging writes a line per request with its status, size and duration.
+func Logging(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := wrap(w)
+			next.ServeHTTP(rw, r)
+
+			traceID, _ := TraceIDFrom(r.Context())
+			logger.Printf("%s %s %d %dB %v trace_id=%s",
+				r.Method, r.URL.Path, rw.Status(), rw.written, time.Since(start), traceID)
+		})
+	}
+}
+
+// Recover turns a panic in the handler into a 500 response, unless
+// the response has already been started. http.ErrAbortHandler is
+// propagated, because it's the way to abort a response on purpose.
+func Recover(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := wrap(w)
+			err := panics.SafeCall(func() error {
+				next.ServeHTTP(rw, r)
+				return nil
+			})
+
+			var panicErr *panics.PanicError
+			if !errors.As(err, &panicErr) {
+				return
+			}
+			if panicErr.Value == http.ErrAbortHandler {
+				panicErr.Repanic()
+			}
+
+			traceID, _ := TraceIDFrom(r.Context())
+			logger.Printf("panic serving %s %s trace_id=%s: %v\n%s",
+				r.Method, r.URL.Path, traceID, panicErr.Value, panicErr.Stack)
+
+			if !rw.wroteHeader() {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import "net/http"
+
+type Middleware func(next http.Handler) http.Handler
+
+// Chain wraps handler so that the first middleware is the outermost one.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		handler = middlewares[idx](handler)
+	}
+	return handler
+}
+
+// responseWriter remembers the status and the size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func wrap(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) wroteHeader() bool {
+	return w.status != 0
+}
+
+// Unwrap lets http.ResponseController reach Flush, Hijack and deadlines.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func traceHandler(w http.ResponseWriter, r *http.Request) {
+	traceID, _ := TraceIDFrom(r.Context())
+	_, _ = io.WriteString(w, traceID)
+}
+
+func TestTraceID(t *testing.T) {
+	tests := map[string]struct {
+		headers  map[string]string
+		expected string
+	}{
+		"traceparent": {
+			headers:  map[string]string{TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			expected: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		"traceparent wins": {
+			headers: map[string]string{
+				TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				RequestIDHeader:   "request-1",
+			},
+			expected: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		"request id": {
+			headers:  map[string]string{RequestIDHeader: "12-21-33"},
+			expected: "12-21-33",
+		},
+		"invalid traceparent": {
+			headers: map[string]string{
+				TraceParentHeader: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				RequestIDHeader:   "request-1",
+			},
+			expected: "request-1",
+		},
+	}
+
+	handler := TraceID(http.HandlerFunc(traceHandler))
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/welcome", nil)
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expected, recorder.Body.String())
+			assert.Equal(t, test.expected, recorder.Header().Get(RequestIDHeader))
+		})
+	}
+}
+
+func TestTraceIDGenerated(t *testing.T) {
+	handler := TraceID(http.HandlerFunc(traceHandler))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(RequestIDHeader, "contains spaces")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	traceID := recorder.Body.String()
+	assert.Len(t, traceID, 32)
+	assert.True(t, isLowerHex(traceID))
+	assert.NotEqual(t, traceID, NewTraceID())
+}
+
+func TestTraceIDKeyIsPrivate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "trace_id", "12-21-33")
+	_, ok := TraceIDFrom(ctx)
+	assert.False(t, ok)
+
+	traceID, ok := TraceIDFrom(WithTraceID(ctx, "abc"))
+	assert.True(t, ok)
+	assert.Equal(t, "abc", traceID)
+}
+
+func TestLogging(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "created")
+	}), TraceID, Logging(log.New(&buffer, "", 0)))
+
+	request := httptest.NewRequest(http.MethodPost, "/items", nil)
+	request.Header.Set(RequestIDHeader, "request-1")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	line := buffer.String()
+	assert.True(t, strings.HasPrefix(line, "POST /items 201 7B "), line)
+	assert.Contains(t, line, "trace_id=request-1")
+}
+
+func TestRecover(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("internal error"))
+	}), TraceID, Recover(log.New(&buffer, "", 0)))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, buffer.String(), "panic serving GET /")
+	assert.Contains(t, buffer.String(), "internal error")
+	assert.Contains(t, buffer.String(), "middleware_test.go")
+}
+
+func TestRecoverAfterWrite(t *testing.T) {
+	handler := Recover(log.New(io.Discard, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late panic")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	handler := Recover(log.New(io.Discard, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	handler := Timeout(20*time.Millisecond, log.New(io.Discard, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			_, _ = io.WriteString(w, "too late")
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestTimeoutIgnoredContext(t *testing.T) {
+	released := make(chan error, 1)
+	handler := Timeout(20*time.Millisecond, log.New(io.Discard, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// doesn't check ctx at all
+		time.Sleep(200 * time.Millisecond)
+		_, err := io.WriteString(w, "too late")
+		released <- err
+	}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	start := time.Now()
+	response, err := http.Get(server.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.NotContains(t, string(body), "too late")
+	assert.ErrorIs(t, <-released, http.ErrHandlerTimeout)
+}
+
+// lineWriter passes every log line to the test
+type lineWriter chan string
+
+func (w lineWriter) Write(data []byte) (int, error) {
+	w <- string(data)
+	return len(data), nil
+}
+
+func TestTimeoutPanicAfterDeadline(t *testing.T) {
+	lines := make(lineWriter, 1)
+	handler := Timeout(20*time.Millisecond, log.New(lines, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		panic("boom")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/late", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	select {
+	case line := <-lines:
+		assert.Contains(t, line, "panic after timeout serving GET /late")
+		assert.Contains(t, line, "boom")
+	case <-time.After(time.Second):
+		t.Fatal("the panic wasn't logged")
+	}
+}
+
+func TestTimeoutFastHandler(t *testing.T) {
+	handler := Timeout(time.Second, log.New(io.Discard, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		w.Header().Set("X-Handler", "fast")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "ok")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "fast", recorder.Header().Get("X-Handler"))
+	assert.Equal(t, "ok", recorder.Body.String())
+}
+
+func TestMaxBodySize(t *testing.T) {
+	handler := MaxBodySize(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("small")))
+	assert.Equal(t, "small", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too large body")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	// chunked body without declared length
+	request := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("too large body")))
+	request.ContentLength = -1
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func TestChainWithServer(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.New(&buffer, "", 0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/welcome", traceHandler)
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	server := httptest.NewServer(Chain(mux, TraceID, Logging(logger), Recover(logger), Timeout(time.Second, logger), MaxBodySize(1024)))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/welcome", nil)
+	require.NoError(t, err)
+	request.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", string(body))
+
+	response, err = http.Get(server.URL + "/panic")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.Contains(t, buffer.String(), "GET /panic 500")
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+	RequestIDHeader   = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// contextKey is unexported, so no other package can collide with these keys.
+type contextKey int
+
+const traceIDKey contextKey = iota
+
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+func TraceIDFrom(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDKey).(string)
+	return traceID, ok
+}
+
+// TraceID takes the trace id from the W3C traceparent header, then from
+// X-Request-ID, and generates a new one if neither is valid. The id is
+// put into the request context and echoed in the X-Request-ID response header.
+func TraceID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, ok := parseTraceParent(r.Header.Get(TraceParentHeader))
+		if !ok {
+			traceID, ok = parseRequestID(r.Header.Get(RequestIDHeader))
+		}
+		if !ok {
+			traceID = NewTraceID()
+		}
+
+		w.Header().Set(RequestIDHeader, traceID)
+		next.ServeHTTP(w, r.WithContext(WithTraceID(r.Context(), traceID)))
+	})
+}
+
+// NewTraceID returns 16 random bytes in hex like a W3C trace id.
+func NewTraceID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// parseTraceParent extracts trace-id from "version-traceid-parentid-flags".
+func parseTraceParent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
+	}
+
+	traceID := parts[1]
+	if len(traceID) != 32 || !isLowerHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+
+	return traceID, true
+}
+
+func parseRequestID(header string) (string, bool) {
+	if header == "" || len(header) > maxRequestIDLength {
+		return "", false
+	}
+
+	for _, symbol := range header {
+		if symbol < 0x21 || symbol > 0x7e {
+			return "", false
+		}
+	}
+
+	return header, true
+}
+
+func isLowerHex(value string) bool {
+	for _, symbol := range value {
+		if (symbol < '0' || symbol > '9') && (symbol < 'a' || symbol > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package monad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func divide(lhs, rhs int) Optional[int] {
+	if rhs == 0 {
+		return None[int]()
+	}
+	return Some(lhs / rhs)
+}
+
+func TestOptional(t *testing.T) {
+	value, ok := divide(100, 0).Get()
+	assert.False(t, ok)
+	assert.Equal(t, 0, value)
+
+	value, ok = divide(100, 5).Get()
+	assert.True(t, ok)
+	assert.Equal(t, 20, value)
+
+	assert.Equal(t, -1, divide(100, 0).OrElse(-1))
+	assert.Equal(t, 20, divide(100, 5).OrElse(-1))
+
+	calls := 0
+	fallback := func() int {
+		calls++
+		return -1
+	}
+	assert.Equal(t, 20, divide(100, 5).OrElseGet(fallback))
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, -1, divide(100, 0).OrElseGet(fallback))
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, 20, divide(100, 5).MustGet())
+	assert.PanicsWithValue(t, ErrNoValue, func() { divide(100, 0).MustGet() })
+
+	var zero Optional[string]
+	assert.False(t, zero.IsPresent())
+}
+
+func TestOptionalConversions(t *testing.T) {
+	values := map[string]int{"one": 1}
+
+	assert.Equal(t, Some(1), FromPair(values["one"], true))
+	number, ok := values["two"]
+	assert.Equal(t, None[int](), FromPair(number, ok))
+
+	assert.Equal(t, None[int](), FromPointer[int](nil))
+	value := 5
+	optional := FromPointer(&value)
+	assert.Equal(t, Some(5), optional)
+
+	// the pointer refers to a copy
+	pointer := optional.Pointer()
+	*pointer = 6
+	assert.Equal(t, 5, optional.MustGet())
+	assert.Nil(t, None[int]().Pointer())
+
+	assert.Equal(t, Ok(5), optional.ToResult(io.EOF))
+	assert.ErrorIs(t, None[int]().ToResult(io.EOF).Err(), io.EOF)
+}
+
+func TestOptionalCombinators(t *testing.T) {
+	double := func(value int) int { return 2 * value }
+	assert.Equal(t, Some(40), Map(divide(100, 5), double))
+	assert.Equal(t, None[int](), Map(divide(100, 0), double))
+
+	format := func(value int) string { return strconv.Itoa(value) }
+	assert.Equal(t, Some("20"), Map(divide(100, 5), format))
+
+	half := func(value int) Optional[int] { return divide(value, 2) }
+	assert.Equal(t, Some(10), FlatMap(divide(100, 5), half))
+	assert.Equal(t, None[int](), FlatMap(divide(100, 5), func(value int) Optional[int] { return divide(value, 0) }))
+	assert.Equal(t, None[int](), FlatMap(divide(100, 0), half))
+
+	even := func(value int) bool { return value%2 == 0 }
+	assert.Equal(t, Some(20), divide(100, 5).Filter(even))
+	assert.Equal(t, None[int](), divide(100, 4).Filter(even))
+
+	assert.Equal(t, "Some(20)", divide(100, 5).String())
+	assert.Equal(t, "None", fmt.Sprint(divide(100, 0)))
+}
+
+func parse(value string) Result[int] {
+	return Of(strconv.Atoi(value))
+}
+
+func TestResult(t *testing.T) {
+	value, err := parse("42").Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	result := parse("forty two")
+	assert.False(t, result.IsOk())
+	assert.ErrorIs(t, result.Err(), strconv.ErrSyntax)
+	assert.Equal(t, -1, result.OrElse(-1))
+	assert.Equal(t, 42, parse("42").OrElse(-1))
+
+	var numErr *strconv.NumError
+	assert.Equal(t, 0, result.OrElseGet(func(err error) int {
+		assert.ErrorAs(t, err, &numErr)
+		return 0
+	}))
+
+	assert.Equal(t, 42, parse("42").MustGet())
+	assert.PanicsWithError(t, result.Err().Error(), func() { result.MustGet() })
+
+	assert.Equal(t, Some(42), parse("42").ToOptional())
+	assert.Equal(t, None[int](), result.ToOptional())
+	assert.ErrorIs(t, Err[int](nil).Err(), ErrNoValue)
+
+	assert.Equal(t, "Ok(42)", parse("42").String())
+	assert.Equal(t, "Err(EOF)", Err[int](io.EOF).String())
+}
+
+func TestResultCombinators(t *testing.T) {
+	double := func(value int) int { return 2 * value }
+	assert.Equal(t, Ok(84), MapResult(parse("42"), double))
+	assert.ErrorIs(t, MapResult(parse("x"), double).Err(), strconv.ErrSyntax)
+
+	positive := func(value int) Result[uint] {
+		if value < 0 {
+			return Err[uint](errors.New("negative"))
+		}
+		return Ok(uint(value))
+	}
+	assert.Equal(t, Ok[uint](42), FlatMapResult(parse("42"), positive))
+	assert.EqualError(t, FlatMapResult(parse("-42"), positive).Err(), "negative")
+	assert.ErrorIs(t, FlatMapResult(parse("x"), positive).Err(), strconv.ErrSyntax)
+
+	wrapped := MapErr(parse("x"), func(err error) error { return fmt.Errorf("parse age: %w", err) })
+	assert.ErrorIs(t, wrapped.Err(), strconv.ErrSyntax)
+	assert.Contains(t, wrapped.Err().Error(), "parse age: ")
+	assert.Equal(t, Ok(42), MapErr(parse("42"), func(err error) error { return io.EOF }))
+}
+
+func TestTry(t *testing.T) {
+	assert.Equal(t, Ok(42), Try(func() (int, error) { return 42, nil }))
+	assert.ErrorIs(t, Try(func() (int, error) { return 0, io.EOF }).Err(), io.EOF)
+
+	result := Try(func() (int, error) {
+		var values []int
+		return values[1], nil
+	})
+	assert.ErrorContains(t, result.Err(), "index out of range")
+
+	result = Try(func() (int, error) { panic(io.ErrUnexpectedEOF) })
+	assert.ErrorIs(t, result.Err(), io.ErrUnexpectedEOF)
+}
+
+type user struct {
+	Name  string           `json:"name"`
+	Age   Optional[int]    `json:"age"`
+	Email Optional[string] `json:"email"`
+	Score Result[float64]  `json:"score"`
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(user{
+		Name:  "Bob",
+		Age:   Some(30),
+		Score: Err[float64](io.EOF),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Bob","age":30,"email":null,"score":null}`, string(data))
+
+	var decoded user
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Alice","age":null,"email":"alice@example.com","score":4.5}`), &decoded))
+	assert.Equal(t, None[int](), decoded.Age)
+	assert.Equal(t, Some("alice@example.com"), decoded.Email)
+	assert.Equal(t, Ok(4.5), decoded.Score)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"score":null}`), &decoded))
+	assert.ErrorIs(t, decoded.Score.Err(), ErrNoValue)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"age":"thirty"}`), &decoded))
+}
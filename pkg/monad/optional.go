@@ -0,0 +1,141 @@
+package monad
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNoValue = errors.New("monad: no value")
+
+// Optional holds a value or nothing. The zero value is empty.
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, present: true}
+}
+
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// FromPair converts the result of a "comma ok" expression.
+func FromPair[T any](value T, ok bool) Optional[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Some(value)
+}
+
+// FromPointer returns an empty Optional for nil.
+func FromPointer[T any](pointer *T) Optional[T] {
+	if pointer == nil {
+		return None[T]()
+	}
+	return Some(*pointer)
+}
+
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+
+// MustGet panics with ErrNoValue if the Optional is empty.
+func (o Optional[T]) MustGet() T {
+	if !o.present {
+		panic(ErrNoValue)
+	}
+	return o.value
+}
+
+func (o Optional[T]) OrElse(value T) T {
+	if o.present {
+		return o.value
+	}
+	return value
+}
+
+// OrElseGet calls fn only if the Optional is empty.
+func (o Optional[T]) OrElseGet(fn func() T) T {
+	if o.present {
+		return o.value
+	}
+	return fn()
+}
+
+func (o Optional[T]) Filter(predicate func(T) bool) Optional[T] {
+	if o.present && predicate(o.value) {
+		return o
+	}
+	return None[T]()
+}
+
+// Pointer returns a pointer to a copy of the value, nil if it's absent.
+func (o Optional[T]) Pointer() *T {
+	if !o.present {
+		return nil
+	}
+	value := o.value
+	return &value
+}
+
+// ToResult converts an empty Optional into a failed Result with err.
+func (o Optional[T]) ToResult(err error) Result[T] {
+	if !o.present {
+		return Err[T](err)
+	}
+	return Ok(o.value)
+}
+
+func (o Optional[T]) String() string {
+	if !o.present {
+		return "None"
+	}
+	return fmt.Sprintf("Some(%v)", o.value)
+}
+
+// MarshalJSON writes null for an empty Optional.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = None[T]()
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*o = Some(value)
+	return nil
+}
+
+// Map and FlatMap are functions, because methods can't have type parameters.
+
+func Map[T, U any](o Optional[T], fn func(T) U) Optional[U] {
+	if !o.present {
+		return None[U]()
+	}
+	return Some(fn(o.value))
+}
+
+func FlatMap[T, U any](o Optional[T], fn func(T) Optional[U]) Optional[U] {
+	if !o.present {
+		return None[U]()
+	}
+	return fn(o.value)
+}
@@ -0,0 +1,137 @@
+package monad
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang_course/pkg/panics"
+)
+
+// Result holds either a value or an error. The zero value
+// is a successful Result with the zero value of T.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err creates a failed Result, a nil err is replaced with ErrNoValue.
+func Err[T any](err error) Result[T] {
+	if err == nil {
+		err = ErrNoValue
+	}
+	return Result[T]{err: err}
+}
+
+// Of converts the usual (value, error) pair.
+func Of[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(value)
+}
+
+// Try calls fn and turns its panic into a *panics.PanicError.
+func Try[T any](fn func() (T, error)) Result[T] {
+	var value T
+	err := panics.SafeCall(func() error {
+		var err error
+		value, err = fn()
+		return err
+	})
+	return Of(value, err)
+}
+
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// MustGet panics with the error of a failed Result.
+func (r Result[T]) MustGet() T {
+	if r.err != nil {
+		panic(r.err)
+	}
+	return r.value
+}
+
+func (r Result[T]) OrElse(value T) T {
+	if r.err != nil {
+		return value
+	}
+	return r.value
+}
+
+// OrElseGet calls fn with the error only if the Result failed.
+func (r Result[T]) OrElseGet(fn func(error) T) T {
+	if r.err != nil {
+		return fn(r.err)
+	}
+	return r.value
+}
+
+// ToOptional drops the error.
+func (r Result[T]) ToOptional() Optional[T] {
+	if r.err != nil {
+		return None[T]()
+	}
+	return Some(r.value)
+}
+
+func (r Result[T]) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("Err(%v)", r.err)
+	}
+	return fmt.Sprintf("Ok(%v)", r.value)
+}
+
+// MarshalJSON writes null for a failed Result, the error isn't written.
+func (r Result[T]) MarshalJSON() ([]byte, error) {
+	if r.err != nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(r.value)
+}
+
+// UnmarshalJSON turns null into a Result failed with ErrNoValue.
+func (r *Result[T]) UnmarshalJSON(data []byte) error {
+	var optional Optional[T]
+	if err := optional.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	*r = optional.ToResult(ErrNoValue)
+	return nil
+}
+
+func MapResult[T, U any](r Result[T], fn func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(fn(r.value))
+}
+
+func FlatMapResult[T, U any](r Result[T], fn func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return fn(r.value)
+}
+
+// MapErr changes the error of a failed Result, e.g. to wrap it.
+func MapErr[T any](r Result[T], fn func(error) error) Result[T] {
+	if r.err != nil {
+		return Err[T](fn(r.err))
+	}
+	return r
+}
@@ -0,0 +1,64 @@
+package panics
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a recovered panic.
+type PanicError struct {
+	// Value is the value passed to panic as is.
+	Value any
+	// Stack is the stack of the panicking goroutine at the moment of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panicked: %v", e.Value)
+}
+
+// Unwrap returns the value if it's an error, so errors.Is
+// and errors.As see the errors passed to panic.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Repanic raises the original value unchanged, which is
+// important for sentinels like http.ErrAbortHandler.
+func (e *PanicError) Repanic() {
+	panic(e.Value)
+}
+
+// SafeCall returns the error of fn or a *PanicError if it panics.
+// runtime.Goexit isn't a panic and can't be stopped, so if fn calls it
+// (e.g. through t.FailNow) SafeCall doesn't return and the goroutine exits.
+func SafeCall(fn func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &PanicError{Value: value, Stack: debug.Stack()}
+		}
+	}()
+
+	return fn()
+}
+
+// SafeGo runs fn in a new goroutine and passes its panic to onPanic.
+// If onPanic is nil, the panic is raised again with the original value.
+func SafeGo(fn func(), onPanic func(*PanicError)) {
+	go func() {
+		err := SafeCall(func() error {
+			fn()
+			return nil
+		})
+		if err == nil {
+			return
+		}
+
+		panicErr := err.(*PanicError)
+		if onPanic == nil {
+			panicErr.Repanic()
+		}
+		onPanic(panicErr)
+	}()
+}
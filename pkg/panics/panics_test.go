@@ -0,0 +1,114 @@
+package panics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeCall(t *testing.T) {
+	assert.NoError(t, SafeCall(func() error { return nil }))
+	assert.Equal(t, io.EOF, SafeCall(func() error { return io.EOF }))
+
+	err := SafeCall(func() error { panic("boom") })
+	assert.EqualError(t, err, "panicked: boom")
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "panics.TestSafeCall")
+	assert.Nil(t, panicErr.Unwrap())
+}
+
+func TestSafeCallRuntimeError(t *testing.T) {
+	err := SafeCall(func() error {
+		var values map[string]int
+		values["key"] = 1
+		return nil
+	})
+
+	var runtimeErr runtime.Error
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Contains(t, err.Error(), "assignment to entry in nil map")
+}
+
+func TestPanicErrorKeepsErrorValue(t *testing.T) {
+	err := SafeCall(func() error { panic(http.ErrAbortHandler) })
+	assert.ErrorIs(t, err, http.ErrAbortHandler)
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, panicErr.Repanic)
+}
+
+func TestSafeCallNilPanic(t *testing.T) {
+	err := SafeCall(func() error { panic(nil) })
+
+	var nilErr *runtime.PanicNilError
+	assert.ErrorAs(t, err, &nilErr)
+}
+
+func TestSafeCallGoexit(t *testing.T) {
+	returned := false
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		_ = SafeCall(func() error {
+			runtime.Goexit()
+			return nil
+		})
+		returned = true
+	}()
+
+	<-exited
+	assert.False(t, returned)
+}
+
+func TestSafeGo(t *testing.T) {
+	recovered := make(chan *PanicError, 1)
+	SafeGo(func() { panic(io.EOF) }, func(err *PanicError) {
+		recovered <- err
+	})
+
+	err := <-recovered
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Contains(t, string(err.Stack), "panics.TestSafeGo")
+
+	done := make(chan struct{})
+	SafeGo(func() { close(done) }, func(err *PanicError) {
+		t.Errorf("unexpected panic: %v", err)
+	})
+	<-done
+}
+
+func TestSafeGoGoexit(t *testing.T) {
+	called := make(chan struct{}, 1)
+	done := make(chan struct{})
+
+	SafeGo(func() {
+		defer close(done)
+		runtime.Goexit()
+	}, func(*PanicError) {
+		called <- struct{}{}
+	})
+
+	<-done
+	select {
+	case <-called:
+		t.Error("Goexit is reported as a panic")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestErrorsIsThroughWrapping(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := SafeCall(func() error { panic(sentinel) })
+	assert.ErrorIs(t, errors.Join(io.EOF, err), sentinel)
+}
@@ -0,0 +1,156 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+var ErrClientClosed = errors.New("protocol: client closed")
+
+// RemoteError is an ERR response from the server.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote error: " + e.Message
+}
+
+// Call is a request in flight, Done is closed when the response arrives.
+type Call struct {
+	Body []byte
+	Err  error
+	Done chan struct{}
+}
+
+// Client sends requests over a single connection. Requests are
+// pipelined: Go writes a request without waiting for the responses
+// to previous ones, which are matched by order.
+type Client struct {
+	conn     net.Conn
+	framer   Framer
+	writeMux sync.Mutex
+
+	mutex   sync.Mutex
+	pending []*Call
+	err     error
+}
+
+func NewClient(conn net.Conn, newFramer NewFramerFunc) *Client {
+	client := &Client{
+		conn:   conn,
+		framer: newFramer(conn),
+	}
+
+	go client.readLoop()
+	return client
+}
+
+// Go sends a request and returns immediately.
+func (c *Client) Go(verb string, body []byte) *Call {
+	call := &Call{Done: make(chan struct{})}
+
+	// the order of pending calls must match the order of written frames
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		call.finish(nil, c.err)
+		return call
+	}
+	c.pending = append(c.pending, call)
+	c.mutex.Unlock()
+
+	if err := c.framer.WriteFrame(encodeRequest(verb, body)); err != nil {
+		c.fail(fmt.Errorf("protocol: write %s: %w", verb, err))
+	}
+
+	return call
+}
+
+// Do sends a request and waits for its response or ctx cancellation.
+// Cancellation doesn't remove the request from the pipeline.
+func (c *Client) Do(ctx context.Context, verb string, body []byte) ([]byte, error) {
+	// Go blocks while the peer isn't reading, so the write is done
+	// on another goroutine, which is released by Close at the latest
+	calls := make(chan *Call, 1)
+	go func() {
+		calls <- c.Go(verb, body)
+	}()
+
+	var call *Call
+	select {
+	case call = <-calls:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case <-call.Done:
+		return call.Body, call.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) Close() error {
+	c.fail(ErrClientClosed)
+	return c.conn.Close()
+}
+
+func (c *Client) readLoop() {
+	for {
+		frame, err := c.framer.ReadFrame()
+		if err != nil {
+			c.fail(fmt.Errorf("protocol: read: %w", err))
+			return
+		}
+
+		c.mutex.Lock()
+		if len(c.pending) == 0 {
+			c.mutex.Unlock()
+			c.fail(errors.New("protocol: unexpected response"))
+			return
+		}
+		call := c.pending[0]
+		c.pending = c.pending[1:]
+		c.mutex.Unlock()
+
+		request := parseRequest(frame)
+		switch request.Verb {
+		case statusOK:
+			call.finish(request.Body, nil)
+		case statusError:
+			call.finish(nil, &RemoteError{Message: string(request.Body)})
+		default:
+			call.finish(nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFrame, request.Verb))
+		}
+	}
+}
+
+// fail finishes all pending calls, the first error is kept for next calls.
+func (c *Client) fail(err error) {
+	c.mutex.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	err = c.err
+	pending := c.pending
+	c.pending = nil
+	c.mutex.Unlock()
+
+	for _, call := range pending {
+		call.finish(nil, err)
+	}
+}
+
+func (call *Call) finish(body []byte, err error) {
+	call.Body = body
+	call.Err = err
+	close(call.Done)
+}
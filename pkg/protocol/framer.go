@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const DefaultMaxFrameSize = 1 << 20
+
+var (
+	ErrFrameTooLarge = errors.New("protocol: frame too large")
+	ErrInvalidFrame  = errors.New("protocol: invalid frame")
+)
+
+// Framer splits a byte stream into frames. ReadFrame and WriteFrame
+// may be called concurrently with each other, but not with themselves.
+type Framer interface {
+	ReadFrame() ([]byte, error)
+	WriteFrame(frame []byte) error
+}
+
+type NewFramerFunc func(rw io.ReadWriter) Framer
+
+type lineFramer struct {
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	maxSize int
+}
+
+// NewLineFramer creates a framer for newline delimited frames,
+// a trailing "\r" is dropped so it also works with telnet and nc.
+func NewLineFramer(rw io.ReadWriter) Framer {
+	return &lineFramer{
+		reader:  bufio.NewReader(rw),
+		writer:  bufio.NewWriter(rw),
+		maxSize: DefaultMaxFrameSize,
+	}
+}
+
+func (f *lineFramer) ReadFrame() ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := f.reader.ReadSlice('\n')
+		if len(frame)+len(chunk) > f.maxSize+1 {
+			return nil, ErrFrameTooLarge
+		}
+
+		frame = append(frame, chunk...)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			if errors.Is(err, io.EOF) && len(frame) != 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+
+	frame = bytes.TrimSuffix(frame, []byte("\n"))
+	frame = bytes.TrimSuffix(frame, []byte("\r"))
+	return frame, nil
+}
+
+func (f *lineFramer) WriteFrame(frame []byte) error {
+	if bytes.IndexByte(frame, '\n') >= 0 {
+		return ErrInvalidFrame
+	}
+	if len(frame) > f.maxSize {
+		return ErrFrameTooLarge
+	}
+
+	if _, err := f.writer.Write(frame); err != nil {
+		return err
+	}
+	if err := f.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	return f.writer.Flush()
+}
+
+type lengthPrefixedFramer struct {
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	maxSize int
+}
+
+// NewLengthPrefixedFramer creates a framer where every frame
+// starts with its length as a big endian uint32.
+func NewLengthPrefixedFramer(rw io.ReadWriter) Framer {
+	return &lengthPrefixedFramer{
+		reader:  bufio.NewReader(rw),
+		writer:  bufio.NewWriter(rw),
+		maxSize: DefaultMaxFrameSize,
+	}
+}
+
+func (f *lengthPrefixedFramer) ReadFrame() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(f.reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > uint32(f.maxSize) {
+		return nil, ErrFrameTooLarge
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(f.reader, frame); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+func (f *lengthPrefixedFramer) WriteFrame(frame []byte) error {
+	if len(frame) > f.maxSize {
+		return ErrFrameTooLarge
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(frame)))
+	if _, err := f.writer.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := f.writer.Write(frame); err != nil {
+		return err
+	}
+	return f.writer.Flush()
+}
@@ -0,0 +1,297 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang_course/pkg/tcpserver"
+)
+
+var _ tcpserver.Handler = (*Router)(nil)
+
+func TestLineFramer(t *testing.T) {
+	var buffer bytes.Buffer
+	framer := NewLineFramer(&buffer)
+
+	require.NoError(t, framer.WriteFrame([]byte("GET key")))
+	require.NoError(t, framer.WriteFrame(nil))
+	assert.ErrorIs(t, framer.WriteFrame([]byte("two\nlines")), ErrInvalidFrame)
+	buffer.WriteString("SET key value\r\n")
+
+	for _, expected := range []string{"GET key", "", "SET key value"} {
+		frame, err := framer.ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(frame))
+	}
+
+	_, err := framer.ReadFrame()
+	assert.ErrorIs(t, err, io.EOF)
+
+	buffer.WriteString("partial")
+	_, err = framer.ReadFrame()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestLineFramerTooLarge(t *testing.T) {
+	buffer := bytes.NewBufferString(strings.Repeat("x", DefaultMaxFrameSize+1) + "\n")
+	_, err := NewLineFramer(buffer).ReadFrame()
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+}
+
+func TestLengthPrefixedFramer(t *testing.T) {
+	var buffer bytes.Buffer
+	framer := NewLengthPrefixedFramer(&buffer)
+
+	frames := [][]byte{[]byte("binary\x00\ndata"), {}, bytes.Repeat([]byte{1}, 1000)}
+	for _, frame := range frames {
+		require.NoError(t, framer.WriteFrame(frame))
+	}
+	assert.Equal(t, []byte{0, 0, 0, 12}, buffer.Bytes()[:4])
+
+	for _, expected := range frames {
+		frame, err := framer.ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, expected, frame)
+	}
+
+	buffer.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	_, err := framer.ReadFrame()
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+
+	buffer.Reset()
+	buffer.Write([]byte{0, 0, 0, 10, 1, 2})
+	_, err = NewLengthPrefixedFramer(&buffer).ReadFrame()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func newStore() *Router {
+	var mutex sync.Mutex
+	data := make(map[string]string)
+
+	router := NewRouter(NewLineFramer)
+	router.Handle("SET", func(ctx context.Context, request Request) ([]byte, error) {
+		key, value, ok := bytes.Cut(request.Body, []byte(" "))
+		if !ok {
+			return nil, errors.New("usage: SET key value")
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		data[string(key)] = string(value)
+		return nil, nil
+	})
+	router.Handle("GET", func(ctx context.Context, request Request) ([]byte, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		value, ok := data[string(request.Body)]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return []byte(value), nil
+	})
+
+	return router
+}
+
+func servePipe(t *testing.T, ctx context.Context, router *Router, newFramer NewFramerFunc) (*Client, <-chan struct{}) {
+	serverConn, clientConn := net.Pipe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer serverConn.Close()
+		router.ServeConn(ctx, serverConn)
+	}()
+
+	client := NewClient(clientConn, newFramer)
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	return client, done
+}
+
+func TestRouterWithClient(t *testing.T) {
+	client, _ := servePipe(t, context.Background(), newStore(), NewLineFramer)
+	ctx := context.Background()
+
+	_, err := client.Do(ctx, "SET", []byte("language go"))
+	require.NoError(t, err)
+
+	value, err := client.Do(ctx, "GET", []byte("language"))
+	require.NoError(t, err)
+	assert.Equal(t, "go", string(value))
+
+	_, err = client.Do(ctx, "GET", []byte("missing"))
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "not found", remoteErr.Message)
+
+	_, err = client.Do(ctx, "DELETE", []byte("language"))
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "unknown command: DELETE", remoteErr.Message)
+}
+
+func TestRouterMultiLineError(t *testing.T) {
+	router := NewRouter(NewLineFramer)
+	router.Handle("FAIL", func(ctx context.Context, request Request) ([]byte, error) {
+		return nil, errors.Join(errors.New("first"), errors.New("second"))
+	})
+	router.Handle("LINES", func(ctx context.Context, request Request) ([]byte, error) {
+		return []byte("two\nlines"), nil
+	})
+	router.Handle("ECHO", func(ctx context.Context, request Request) ([]byte, error) {
+		return request.Body, nil
+	})
+
+	client, _ := servePipe(t, context.Background(), router, NewLineFramer)
+
+	failed := client.Go("FAIL", nil)
+	lines := client.Go("LINES", nil)
+	echoed := client.Go("ECHO", []byte("still serving"))
+
+	<-failed.Done
+	var remoteErr *RemoteError
+	require.ErrorAs(t, failed.Err, &remoteErr)
+	assert.Equal(t, "response can't be framed: protocol: invalid frame", remoteErr.Message)
+
+	<-lines.Done
+	require.ErrorAs(t, lines.Err, &remoteErr)
+	assert.Equal(t, "response can't be framed: protocol: invalid frame", remoteErr.Message)
+
+	<-echoed.Done
+	require.NoError(t, echoed.Err)
+	assert.Equal(t, "still serving", string(echoed.Body))
+}
+
+func TestClientPipelining(t *testing.T) {
+	router := NewRouter(NewLengthPrefixedFramer)
+	router.Handle("ECHO", func(ctx context.Context, request Request) ([]byte, error) {
+		return request.Body, nil
+	})
+
+	client, _ := servePipe(t, context.Background(), router, NewLengthPrefixedFramer)
+
+	calls := make([]*Call, 100)
+	for i := range calls {
+		calls[i] = client.Go("ECHO", []byte{byte(i), '\n'})
+	}
+
+	for i, call := range calls {
+		<-call.Done
+		require.NoError(t, call.Err)
+		assert.Equal(t, []byte{byte(i), '\n'}, call.Body)
+	}
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	client, _ := servePipe(t, context.Background(), newStore(), NewLineFramer)
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			key := []byte{byte('a' + i)}
+
+			_, err := client.Do(ctx, "SET", append(key, " value"...))
+			assert.NoError(t, err)
+			value, err := client.Do(ctx, "GET", key)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", string(value))
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestRouterStopsOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	router := NewRouter(NewLineFramer)
+	router.Handle("SLOW", func(ctx context.Context, request Request) ([]byte, error) {
+		close(started)
+		<-ctx.Done()
+		return []byte("interrupted"), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, done := servePipe(t, ctx, router, NewLineFramer)
+
+	call := client.Go("SLOW", nil)
+	<-started
+	cancel()
+
+	// the request in flight is still answered
+	<-call.Done
+	require.NoError(t, call.Err)
+	assert.Equal(t, "interrupted", string(call.Body))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("connection wasn't closed on shutdown")
+	}
+
+	_, err := client.Do(context.Background(), "SLOW", nil)
+	assert.Error(t, err)
+}
+
+func TestClientDoWriteTimeout(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+
+	// nobody reads from serverConn, so writes block
+	client := NewClient(clientConn, NewLineFramer)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Do(ctx, "GET", []byte("key"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClientClose(t *testing.T) {
+	client, _ := servePipe(t, context.Background(), newStore(), NewLineFramer)
+	require.NoError(t, client.Close())
+
+	_, err := client.Do(context.Background(), "GET", []byte("key"))
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
+
+func TestRouterWithTCPServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &tcpserver.Server{Handler: newStore()}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	client := NewClient(conn, NewLineFramer)
+	defer client.Close()
+
+	_, err = client.Do(context.Background(), "SET", []byte("key value"))
+	require.NoError(t, err)
+	value, err := client.Do(context.Background(), "GET", []byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+}
@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+const (
+	statusOK    = "OK"
+	statusError = "ERR"
+)
+
+// Request is a frame in the form "VERB body", the body may be empty.
+type Request struct {
+	Verb string
+	Body []byte
+}
+
+// HandlerFunc handles one request, the returned error is sent
+// to the client as an ERR response with the error text.
+type HandlerFunc func(ctx context.Context, request Request) ([]byte, error)
+
+// Router dispatches requests to handlers by verb. It implements
+// tcpserver.Handler, requests of a connection are served one by one,
+// so responses always come back in the order of requests.
+type Router struct {
+	newFramer NewFramerFunc
+	mutex     sync.RWMutex
+	handlers  map[string]HandlerFunc
+}
+
+func NewRouter(newFramer NewFramerFunc) *Router {
+	return &Router{
+		newFramer: newFramer,
+		handlers:  make(map[string]HandlerFunc),
+	}
+}
+
+func (r *Router) Handle(verb string, handler HandlerFunc) {
+	if verb == "" || bytes.ContainsRune([]byte(verb), ' ') {
+		panic("protocol: invalid verb " + verb)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handlers[verb] = handler
+}
+
+// ServeConn reads requests until the connection fails or ctx is cancelled,
+// the request being handled at that moment still gets its response.
+// A response the framer can't carry is replaced with an ERR response.
+func (r *Router) ServeConn(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(ctx, func() {
+		// unblocks ReadFrame
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	framer := r.newFramer(conn)
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return
+		}
+
+		body, err := r.serve(ctx, parseRequest(frame))
+		err = framer.WriteFrame(encodeResponse(body, err))
+		if errors.Is(err, ErrInvalidFrame) || errors.Is(err, ErrFrameTooLarge) {
+			// e.g. a multi-line error over the line framer, nothing has
+			// been written, so the client gets a one-line error instead
+			err = framer.WriteFrame(encodeResponse(nil, fmt.Errorf("response can't be framed: %w", err)))
+		}
+		if err != nil {
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (r *Router) serve(ctx context.Context, request Request) ([]byte, error) {
+	r.mutex.RLock()
+	handler, ok := r.handlers[request.Verb]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCommand, request.Verb)
+	}
+
+	return handler(ctx, request)
+}
+
+func parseRequest(frame []byte) Request {
+	verb, body, _ := bytes.Cut(frame, []byte(" "))
+	return Request{Verb: string(verb), Body: body}
+}
+
+func encodeRequest(verb string, body []byte) []byte {
+	frame := make([]byte, 0, len(verb)+1+len(body))
+	frame = append(frame, verb...)
+	if len(body) != 0 {
+		frame = append(frame, ' ')
+		frame = append(frame, body...)
+	}
+	return frame
+}
+
+func encodeResponse(body []byte, err error) []byte {
+	if err != nil {
+		return encodeRequest(statusError, []byte(err.Error()))
+	}
+	return encodeRequest(statusOK, body)
+}
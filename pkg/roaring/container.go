@@ -0,0 +1,348 @@
+package roaring
+
+import (
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+const (
+	// arrayMaxSize is the cardinality at which an array
+	// takes as much memory as a bitmap container
+	arrayMaxSize = 4096
+	bitmapWords  = (1 << 16) / 64
+	bitmapBytes  = bitmapWords * 8
+)
+
+// container holds the lower 16 bits of the values sharing the upper 16 bits.
+// Methods that change the container return it, because it may be
+// converted to another type on the way.
+type container interface {
+	add(value uint16) container
+	remove(value uint16) container
+	contains(value uint16) bool
+	cardinality() int
+	iterate(fn func(value uint16) bool) bool
+	clone() container
+	sizeInBytes() int
+	// toBitmap may return the container itself, the result must not be changed
+	toBitmap() *bitmapContainer
+}
+
+// arrayContainer is a sorted array used for sparse containers.
+type arrayContainer struct {
+	values []uint16
+}
+
+func (a *arrayContainer) add(value uint16) container {
+	idx, found := slices.BinarySearch(a.values, value)
+	if found {
+		return a
+	}
+
+	if len(a.values) >= arrayMaxSize {
+		bitmap := a.toBitmap()
+		return bitmap.add(value)
+	}
+
+	a.values = slices.Insert(a.values, idx, value)
+	return a
+}
+
+func (a *arrayContainer) remove(value uint16) container {
+	if idx, found := slices.BinarySearch(a.values, value); found {
+		a.values = slices.Delete(a.values, idx, idx+1)
+	}
+	return a
+}
+
+func (a *arrayContainer) contains(value uint16) bool {
+	_, found := slices.BinarySearch(a.values, value)
+	return found
+}
+
+func (a *arrayContainer) cardinality() int {
+	return len(a.values)
+}
+
+func (a *arrayContainer) iterate(fn func(value uint16) bool) bool {
+	for _, value := range a.values {
+		if !fn(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *arrayContainer) clone() container {
+	return &arrayContainer{values: slices.Clone(a.values)}
+}
+
+func (a *arrayContainer) sizeInBytes() int {
+	return 2 * len(a.values)
+}
+
+func (a *arrayContainer) toBitmap() *bitmapContainer {
+	bitmap := &bitmapContainer{}
+	for _, value := range a.values {
+		bitmap.words[value/64] |= 1 << (value % 64)
+	}
+	bitmap.card = len(a.values)
+	return bitmap
+}
+
+// bitmapContainer is a bitset of all 2^16 values used for dense containers.
+type bitmapContainer struct {
+	words [bitmapWords]uint64
+	card  int
+}
+
+func (b *bitmapContainer) add(value uint16) container {
+	mask := uint64(1) << (value % 64)
+	if b.words[value/64]&mask == 0 {
+		b.words[value/64] |= mask
+		b.card++
+	}
+	return b
+}
+
+func (b *bitmapContainer) remove(value uint16) container {
+	mask := uint64(1) << (value % 64)
+	if b.words[value/64]&mask == 0 {
+		return b
+	}
+
+	b.words[value/64] &^= mask
+	b.card--
+	if b.card <= arrayMaxSize {
+		return b.toArray()
+	}
+	return b
+}
+
+func (b *bitmapContainer) contains(value uint16) bool {
+	return b.words[value/64]&(1<<(value%64)) != 0
+}
+
+func (b *bitmapContainer) cardinality() int {
+	return b.card
+}
+
+func (b *bitmapContainer) iterate(fn func(value uint16) bool) bool {
+	for idx, word := range b.words {
+		for word != 0 {
+			if !fn(uint16(idx*64 + bits.TrailingZeros64(word))) {
+				return false
+			}
+			word &= word - 1
+		}
+	}
+	return true
+}
+
+func (b *bitmapContainer) clone() container {
+	clone := *b
+	return &clone
+}
+
+func (b *bitmapContainer) sizeInBytes() int {
+	return bitmapBytes
+}
+
+func (b *bitmapContainer) toBitmap() *bitmapContainer {
+	return b
+}
+
+func (b *bitmapContainer) toArray() *arrayContainer {
+	values := make([]uint16, 0, b.card)
+	b.iterate(func(value uint16) bool {
+		values = append(values, value)
+		return true
+	})
+	return &arrayContainer{values: values}
+}
+
+func (b *bitmapContainer) recount() {
+	b.card = 0
+	for _, word := range b.words {
+		b.card += bits.OnesCount64(word)
+	}
+}
+
+// runs counts the sequences of consecutive set bits.
+func (b *bitmapContainer) runs() int {
+	count := 0
+	carry := uint64(0)
+	for _, word := range b.words {
+		// a run starts at a set bit whose lower neighbour isn't set
+		count += bits.OnesCount64(word &^ (word<<1 | carry))
+		carry = word >> 63
+	}
+	return count
+}
+
+// interval is a run of values from start to last inclusive.
+type interval struct {
+	start uint16
+	last  uint16
+}
+
+// runContainer stores sorted non-adjacent runs, it's used for long sequences.
+type runContainer struct {
+	runs []interval
+}
+
+// append adds a value greater than all values in the container.
+func (r *runContainer) append(value uint16) {
+	if count := len(r.runs); count > 0 && r.runs[count-1].last+1 == value {
+		r.runs[count-1].last = value
+		return
+	}
+	r.runs = append(r.runs, interval{start: value, last: value})
+}
+
+// add and remove convert the container, since changing runs in
+// the middle is expensive. RunOptimize converts it back if it pays off.
+func (r *runContainer) add(value uint16) container {
+	if r.contains(value) {
+		return r
+	}
+	return normalize(r, false).add(value)
+}
+
+func (r *runContainer) remove(value uint16) container {
+	if !r.contains(value) {
+		return r
+	}
+	return normalize(r, false).remove(value)
+}
+
+func (r *runContainer) contains(value uint16) bool {
+	idx := sort.Search(len(r.runs), func(idx int) bool {
+		return r.runs[idx].start > value
+	}) - 1
+	return idx >= 0 && value <= r.runs[idx].last
+}
+
+func (r *runContainer) cardinality() int {
+	count := 0
+	for _, run := range r.runs {
+		count += int(run.last-run.start) + 1
+	}
+	return count
+}
+
+func (r *runContainer) iterate(fn func(value uint16) bool) bool {
+	for _, run := range r.runs {
+		for value := int(run.start); value <= int(run.last); value++ {
+			if !fn(uint16(value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *runContainer) clone() container {
+	return &runContainer{runs: slices.Clone(r.runs)}
+}
+
+func (r *runContainer) sizeInBytes() int {
+	return 4 * len(r.runs)
+}
+
+func (r *runContainer) toBitmap() *bitmapContainer {
+	bitmap := &bitmapContainer{}
+	for _, run := range r.runs {
+		setRange(&bitmap.words, int(run.start), int(run.last)+1)
+	}
+	bitmap.recount()
+	return bitmap
+}
+
+// setRange sets the bits from start to end exclusive.
+func setRange(words *[bitmapWords]uint64, start, end int) {
+	for start < end {
+		idx := start / 64
+		from := start % 64
+		to := min(end-idx*64, 64)
+		mask := ^uint64(0) << from
+		if to < 64 {
+			mask &= (1 << to) - 1
+		}
+		words[idx] |= mask
+		start = (idx + 1) * 64
+	}
+}
+
+// normalize converts the container to the type taking the least memory.
+// Runs are considered only if allowRuns is set. Nil is returned for an
+// empty container.
+func normalize(c container, allowRuns bool) container {
+	card := c.cardinality()
+	if card == 0 {
+		return nil
+	}
+
+	arraySize := 2 * card
+	if card > arrayMaxSize {
+		arraySize = bitmapBytes + 1
+	}
+
+	if allowRuns && 4*countRuns(c) < min(arraySize, bitmapBytes) {
+		return toRuns(c)
+	}
+	if arraySize <= bitmapBytes {
+		return toArray(c)
+	}
+	return c.toBitmap()
+}
+
+func countRuns(c container) int {
+	switch c := c.(type) {
+	case *runContainer:
+		return len(c.runs)
+	case *bitmapContainer:
+		return c.runs()
+	}
+
+	count := 0
+	previous := -2
+	c.iterate(func(value uint16) bool {
+		if int(value) != previous+1 {
+			count++
+		}
+		previous = int(value)
+		return true
+	})
+	return count
+}
+
+func toRuns(c container) *runContainer {
+	if runs, ok := c.(*runContainer); ok {
+		return runs
+	}
+
+	runs := &runContainer{}
+	c.iterate(func(value uint16) bool {
+		runs.append(value)
+		return true
+	})
+	return runs
+}
+
+func toArray(c container) *arrayContainer {
+	switch c := c.(type) {
+	case *arrayContainer:
+		return c
+	case *bitmapContainer:
+		return c.toArray()
+	}
+
+	values := make([]uint16, 0, c.cardinality())
+	c.iterate(func(value uint16) bool {
+		values = append(values, value)
+		return true
+	})
+	return &arrayContainer{values: values}
+}
@@ -0,0 +1,99 @@
+package roaring
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Index keeps a bitmap of rows per attribute, e.g. per feature of a
+// restaurant, and answers which rows have some attributes and don't
+// have others without scanning the rows.
+type Index[K comparable] struct {
+	rows       *Bitmap
+	attributes map[K]*Bitmap
+}
+
+func NewIndex[K comparable]() *Index[K] {
+	return &Index[K]{
+		rows:       New(),
+		attributes: make(map[K]*Bitmap),
+	}
+}
+
+// Add registers the row and marks it with the attributes.
+func (ix *Index[K]) Add(row uint32, attributes ...K) {
+	ix.rows.Add(row)
+	for _, attribute := range attributes {
+		bitmap, ok := ix.attributes[attribute]
+		if !ok {
+			bitmap = New()
+			ix.attributes[attribute] = bitmap
+		}
+		bitmap.Add(row)
+	}
+}
+
+// Remove deletes the attributes of the row, the row stays registered.
+func (ix *Index[K]) Remove(row uint32, attributes ...K) {
+	for _, attribute := range attributes {
+		if bitmap, ok := ix.attributes[attribute]; ok {
+			bitmap.Remove(row)
+		}
+	}
+}
+
+// Rows returns all registered rows, the result must not be changed.
+func (ix *Index[K]) Rows() *Bitmap {
+	return ix.rows
+}
+
+// Bitmap returns the rows having the attribute, the result must not be changed.
+func (ix *Index[K]) Bitmap(attribute K) *Bitmap {
+	if bitmap, ok := ix.attributes[attribute]; ok {
+		return bitmap
+	}
+	return New()
+}
+
+// Query returns the rows having all attributes from with and none from
+// without. Without required attributes it starts from all rows.
+func (ix *Index[K]) Query(with, without []K) *Bitmap {
+	required := make([]*Bitmap, 0, len(with))
+	for _, attribute := range with {
+		required = append(required, ix.Bitmap(attribute))
+	}
+
+	// the smallest bitmap first keeps the intermediate results small
+	slices.SortFunc(required, func(lhs, rhs *Bitmap) int {
+		return cmp.Compare(lhs.Cardinality(), rhs.Cardinality())
+	})
+
+	var result *Bitmap
+	if len(required) == 0 {
+		result = ix.rows.Clone()
+	} else {
+		result = required[0].Clone()
+		for _, bitmap := range required[1:] {
+			if result.IsEmpty() {
+				return result
+			}
+			result = And(result, bitmap)
+		}
+	}
+
+	for _, attribute := range without {
+		if result.IsEmpty() {
+			return result
+		}
+		result = AndNot(result, ix.Bitmap(attribute))
+	}
+	return result
+}
+
+// RunOptimize compresses all bitmaps of the index.
+func (ix *Index[K]) RunOptimize() {
+	ix.rows.RunOptimize()
+	for _, bitmap := range ix.attributes {
+		bitmap.RunOptimize()
+	}
+}
@@ -0,0 +1,126 @@
+package roaring
+
+// Operations on two containers return a new container and never change
+// their arguments. Arrays are merged directly, other combinations are
+// computed word by word on bitmaps.
+
+func and(a, b container) container {
+	if _, ok := b.(*arrayContainer); ok {
+		a, b = b, a
+	}
+
+	if array, ok := a.(*arrayContainer); ok {
+		values := make([]uint16, 0, min(array.cardinality(), b.cardinality()))
+		for _, value := range array.values {
+			if b.contains(value) {
+				values = append(values, value)
+			}
+		}
+		return &arrayContainer{values: values}
+	}
+
+	return combine(a.toBitmap(), b.toBitmap(), opAnd)
+}
+
+func or(a, b container) container {
+	lhs, lok := a.(*arrayContainer)
+	rhs, rok := b.(*arrayContainer)
+	if lok && rok {
+		return &arrayContainer{values: merge(lhs.values, rhs.values, true, true, true)}
+	}
+
+	return combine(a.toBitmap(), b.toBitmap(), opOr)
+}
+
+func andNot(a, b container) container {
+	if array, ok := a.(*arrayContainer); ok {
+		values := make([]uint16, 0, array.cardinality())
+		for _, value := range array.values {
+			if !b.contains(value) {
+				values = append(values, value)
+			}
+		}
+		return &arrayContainer{values: values}
+	}
+
+	return combine(a.toBitmap(), b.toBitmap(), opAndNot)
+}
+
+func xor(a, b container) container {
+	lhs, lok := a.(*arrayContainer)
+	rhs, rok := b.(*arrayContainer)
+	if lok && rok {
+		return &arrayContainer{values: merge(lhs.values, rhs.values, true, false, true)}
+	}
+
+	return combine(a.toBitmap(), b.toBitmap(), opXor)
+}
+
+type operation int
+
+const (
+	opAnd operation = iota
+	opOr
+	opAndNot
+	opXor
+)
+
+// combine switches outside of the loops, so they stay simple enough to be fast.
+func combine(a, b *bitmapContainer, op operation) *bitmapContainer {
+	result := &bitmapContainer{}
+	switch op {
+	case opAnd:
+		for idx := range result.words {
+			result.words[idx] = a.words[idx] & b.words[idx]
+		}
+	case opOr:
+		for idx := range result.words {
+			result.words[idx] = a.words[idx] | b.words[idx]
+		}
+	case opAndNot:
+		for idx := range result.words {
+			result.words[idx] = a.words[idx] &^ b.words[idx]
+		}
+	case opXor:
+		for idx := range result.words {
+			result.words[idx] = a.words[idx] ^ b.words[idx]
+		}
+	}
+	result.recount()
+	return result
+}
+
+// merge walks two sorted arrays and keeps the values present
+// only in the left one, in both of them or only in the right one.
+func merge(lhs, rhs []uint16, onlyLeft, both, onlyRight bool) []uint16 {
+	result := make([]uint16, 0, len(lhs)+len(rhs))
+	i, j := 0, 0
+	for i < len(lhs) && j < len(rhs) {
+		switch {
+		case lhs[i] < rhs[j]:
+			if onlyLeft {
+				result = append(result, lhs[i])
+			}
+			i++
+		case lhs[i] > rhs[j]:
+			if onlyRight {
+				result = append(result, rhs[j])
+			}
+			j++
+		default:
+			if both {
+				result = append(result, lhs[i])
+			}
+			i++
+			j++
+		}
+	}
+
+	if onlyLeft {
+		result = append(result, lhs[i:]...)
+	}
+	if onlyRight {
+		result = append(result, rhs[j:]...)
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+// Package roaring implements compressed bitmaps of uint32 in the spirit
+// of Roaring bitmaps. Values are split by their upper 16 bits into
+// containers, each stored as a sorted array, a bitset or a list of runs,
+// whichever is the smallest.
+package roaring
+
+import (
+	"iter"
+	"slices"
+)
+
+// Bitmap is a set of uint32. The zero value is an empty set.
+type Bitmap struct {
+	keys       []uint16
+	containers []container
+}
+
+func New() *Bitmap {
+	return &Bitmap{}
+}
+
+func Of(values ...uint32) *Bitmap {
+	bitmap := New()
+	for _, value := range values {
+		bitmap.Add(value)
+	}
+	return bitmap
+}
+
+func split(value uint32) (uint16, uint16) {
+	return uint16(value >> 16), uint16(value)
+}
+
+func (b *Bitmap) find(key uint16) (int, bool) {
+	return slices.BinarySearch(b.keys, key)
+}
+
+func (b *Bitmap) Add(value uint32) {
+	key, low := split(value)
+	idx, found := b.find(key)
+	if found {
+		b.containers[idx] = b.containers[idx].add(low)
+		return
+	}
+
+	b.keys = slices.Insert(b.keys, idx, key)
+	b.containers = slices.Insert(b.containers, idx, container(&arrayContainer{values: []uint16{low}}))
+}
+
+// AddRange adds the values from start to end exclusive, end
+// can be 1<<32. Long ranges are stored as runs.
+func (b *Bitmap) AddRange(start, end uint64) {
+	end = min(end, 1<<32)
+	for start < end {
+		key := uint16(start >> 16)
+		last := min(end, (start|0xffff)+1) - 1
+
+		runs := &runContainer{runs: []interval{{start: uint16(start), last: uint16(last)}}}
+		idx, found := b.find(key)
+		if found {
+			b.containers[idx] = normalize(or(b.containers[idx], runs), true)
+		} else {
+			b.keys = slices.Insert(b.keys, idx, key)
+			b.containers = slices.Insert(b.containers, idx, normalize(runs, true))
+		}
+
+		start = last + 1
+	}
+}
+
+func (b *Bitmap) Remove(value uint32) {
+	key, low := split(value)
+	idx, found := b.find(key)
+	if !found {
+		return
+	}
+
+	c := b.containers[idx].remove(low)
+	if c.cardinality() == 0 {
+		b.keys = slices.Delete(b.keys, idx, idx+1)
+		b.containers = slices.Delete(b.containers, idx, idx+1)
+		return
+	}
+	b.containers[idx] = c
+}
+
+func (b *Bitmap) Contains(value uint32) bool {
+	key, low := split(value)
+	idx, found := b.find(key)
+	return found && b.containers[idx].contains(low)
+}
+
+func (b *Bitmap) Cardinality() uint64 {
+	var count uint64
+	for _, c := range b.containers {
+		count += uint64(c.cardinality())
+	}
+	return count
+}
+
+func (b *Bitmap) IsEmpty() bool {
+	return len(b.containers) == 0
+}
+
+// Iterate calls fn for the values in ascending order until it returns false.
+func (b *Bitmap) Iterate(fn func(value uint32) bool) {
+	for idx, c := range b.containers {
+		high := uint32(b.keys[idx]) << 16
+		if !c.iterate(func(low uint16) bool { return fn(high | uint32(low)) }) {
+			return
+		}
+	}
+}
+
+// Values returns an iterator over the values in ascending order.
+func (b *Bitmap) Values() iter.Seq[uint32] {
+	return b.Iterate
+}
+
+func (b *Bitmap) ToSlice() []uint32 {
+	values := make([]uint32, 0, b.Cardinality())
+	b.Iterate(func(value uint32) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+func (b *Bitmap) Clone() *Bitmap {
+	clone := &Bitmap{
+		keys:       slices.Clone(b.keys),
+		containers: make([]container, len(b.containers)),
+	}
+	for idx, c := range b.containers {
+		clone.containers[idx] = c.clone()
+	}
+	return clone
+}
+
+func (b *Bitmap) Equals(other *Bitmap) bool {
+	if !slices.Equal(b.keys, other.keys) {
+		return false
+	}
+
+	for idx, c := range b.containers {
+		if c.cardinality() != other.containers[idx].cardinality() {
+			return false
+		}
+		if xor(c, other.containers[idx]).cardinality() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RunOptimize converts every container to the smallest representation,
+// including runs, which Add doesn't create on its own.
+func (b *Bitmap) RunOptimize() {
+	for idx, c := range b.containers {
+		b.containers[idx] = normalize(c, true)
+	}
+}
+
+// SizeInBytes estimates the memory taken by the values.
+func (b *Bitmap) SizeInBytes() int {
+	size := 2 * len(b.keys)
+	for _, c := range b.containers {
+		size += c.sizeInBytes()
+	}
+	return size
+}
+
+func And(a, b *Bitmap) *Bitmap {
+	return combineBitmaps(a, b, and, false, false)
+}
+
+func Or(a, b *Bitmap) *Bitmap {
+	return combineBitmaps(a, b, or, true, true)
+}
+
+// AndNot returns the values of a which are not in b.
+func AndNot(a, b *Bitmap) *Bitmap {
+	return combineBitmaps(a, b, andNot, true, false)
+}
+
+func Xor(a, b *Bitmap) *Bitmap {
+	return combineBitmaps(a, b, xor, true, true)
+}
+
+// combineBitmaps applies op to the containers with the same key, the
+// containers present in a single bitmap are copied if the flag is set.
+func combineBitmaps(a, b *Bitmap, op func(x, y container) container, keepLeft, keepRight bool) *Bitmap {
+	result := New()
+	appendContainer := func(key uint16, c container) {
+		if c != nil {
+			result.keys = append(result.keys, key)
+			result.containers = append(result.containers, c)
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a.keys) && j < len(b.keys) {
+		switch {
+		case a.keys[i] < b.keys[j]:
+			if keepLeft {
+				appendContainer(a.keys[i], a.containers[i].clone())
+			}
+			i++
+		case a.keys[i] > b.keys[j]:
+			if keepRight {
+				appendContainer(b.keys[j], b.containers[j].clone())
+			}
+			j++
+		default:
+			appendContainer(a.keys[i], normalize(op(a.containers[i], b.containers[j]), true))
+			i++
+			j++
+		}
+	}
+
+	for ; keepLeft && i < len(a.keys); i++ {
+		appendContainer(a.keys[i], a.containers[i].clone())
+	}
+	for ; keepRight && j < len(b.keys); j++ {
+		appendContainer(b.keys[j], b.containers[j].clone())
+	}
+	return result
+}
@@ -0,0 +1,318 @@
+package roaring
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type set map[uint32]struct{}
+
+func (s set) sorted() []uint32 {
+	values := make([]uint32, 0, len(s))
+	for value := range s {
+		values = append(values, value)
+	}
+	slices.Sort(values)
+	return values
+}
+
+// randomBitmap mixes sparse values, dense blocks and long runs,
+// so all container types and their combinations are involved.
+func randomBitmap(random *rand.Rand) (*Bitmap, set) {
+	bitmap, reference := New(), set{}
+	add := func(value uint32) {
+		bitmap.Add(value)
+		reference[value] = struct{}{}
+	}
+
+	for range random.IntN(2000) {
+		add(random.Uint32N(4 << 16))
+	}
+
+	if random.IntN(2) == 0 {
+		high := random.Uint32N(4) << 16
+		for range 5000 + random.IntN(20000) {
+			add(high | random.Uint32N(1<<16))
+		}
+	}
+
+	for range random.IntN(3) {
+		start := uint64(random.Uint32N(4 << 16))
+		end := start + uint64(random.IntN(100000))
+		bitmap.AddRange(start, end)
+		for value := start; value < end; value++ {
+			reference[uint32(value)] = struct{}{}
+		}
+	}
+
+	if random.IntN(2) == 0 {
+		bitmap.RunOptimize()
+	}
+	return bitmap, reference
+}
+
+func TestBitmapBasics(t *testing.T) {
+	bitmap := Of(1, 5, 1<<16, 1<<31, 5)
+
+	assert.Equal(t, uint64(4), bitmap.Cardinality())
+	assert.True(t, bitmap.Contains(5))
+	assert.False(t, bitmap.Contains(6))
+	assert.Equal(t, []uint32{1, 5, 1 << 16, 1 << 31}, bitmap.ToSlice())
+
+	bitmap.Remove(5)
+	bitmap.Remove(1 << 16)
+	bitmap.Remove(42)
+	assert.Equal(t, []uint32{1, 1 << 31}, bitmap.ToSlice())
+	assert.Len(t, bitmap.keys, 2)
+
+	var empty Bitmap
+	assert.True(t, empty.IsEmpty())
+	assert.False(t, empty.Contains(0))
+	empty.Add(0)
+	assert.True(t, empty.Contains(0))
+}
+
+func TestBitmapIteration(t *testing.T) {
+	bitmap := Of(3, 1, 2, 100000)
+
+	var values []uint32
+	for value := range bitmap.Values() {
+		if value > 2 {
+			break
+		}
+		values = append(values, value)
+	}
+	assert.Equal(t, []uint32{1, 2}, values)
+}
+
+func TestContainerConversions(t *testing.T) {
+	bitmap := New()
+	for value := range uint32(arrayMaxSize) {
+		bitmap.Add(2 * value)
+	}
+	assert.IsType(t, &arrayContainer{}, bitmap.containers[0])
+
+	bitmap.Add(1)
+	assert.IsType(t, &bitmapContainer{}, bitmap.containers[0])
+
+	bitmap.Remove(1)
+	assert.IsType(t, &arrayContainer{}, bitmap.containers[0])
+	assert.Equal(t, uint64(arrayMaxSize), bitmap.Cardinality())
+
+	bitmap = New()
+	bitmap.AddRange(10, 60000)
+	assert.IsType(t, &runContainer{}, bitmap.containers[0])
+	assert.Equal(t, uint64(59990), bitmap.Cardinality())
+	assert.Less(t, bitmap.SizeInBytes(), 16)
+
+	// changing a run container converts it
+	bitmap.Remove(100)
+	assert.IsType(t, &bitmapContainer{}, bitmap.containers[0])
+	bitmap.RunOptimize()
+	assert.IsType(t, &runContainer{}, bitmap.containers[0])
+	assert.False(t, bitmap.Contains(100))
+	assert.True(t, bitmap.Contains(101))
+}
+
+func TestAddRange(t *testing.T) {
+	bitmap := Of(5, 70000)
+	bitmap.AddRange(65530, 65540)
+	bitmap.AddRange(100, 100)
+
+	expected := []uint32{5}
+	for value := uint32(65530); value < 65540; value++ {
+		expected = append(expected, value)
+	}
+	assert.Equal(t, append(expected, 70000), bitmap.ToSlice())
+
+	full := New()
+	full.AddRange(0, 1<<32)
+	assert.Equal(t, uint64(1<<32), full.Cardinality())
+	assert.True(t, full.Contains(1<<32-1))
+	assert.Less(t, full.SizeInBytes(), 1<<20)
+}
+
+func TestOperations(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+
+	operations := map[string]struct {
+		bitmap    func(a, b *Bitmap) *Bitmap
+		reference func(inA, inB bool) bool
+	}{
+		"and":    {And, func(inA, inB bool) bool { return inA && inB }},
+		"or":     {Or, func(inA, inB bool) bool { return inA || inB }},
+		"andNot": {AndNot, func(inA, inB bool) bool { return inA && !inB }},
+		"xor":    {Xor, func(inA, inB bool) bool { return inA != inB }},
+	}
+
+	for range 30 {
+		a, referenceA := randomBitmap(random)
+		b, referenceB := randomBitmap(random)
+		require.Equal(t, referenceA.sorted(), a.ToSlice())
+		require.Equal(t, uint64(len(referenceA)), a.Cardinality())
+
+		all := set{}
+		for value := range referenceA {
+			all[value] = struct{}{}
+		}
+		for value := range referenceB {
+			all[value] = struct{}{}
+		}
+
+		for name, operation := range operations {
+			expected := set{}
+			for value := range all {
+				_, inA := referenceA[value]
+				_, inB := referenceB[value]
+				if operation.reference(inA, inB) {
+					expected[value] = struct{}{}
+				}
+			}
+
+			result := operation.bitmap(a, b)
+			require.Equal(t, expected.sorted(), result.ToSlice(), name)
+			require.Equal(t, uint64(len(expected)), result.Cardinality(), name)
+		}
+
+		// the arguments are not changed
+		require.Equal(t, referenceA.sorted(), a.ToSlice())
+		require.Equal(t, referenceB.sorted(), b.ToSlice())
+	}
+}
+
+func TestCloneAndEquals(t *testing.T) {
+	random := rand.New(rand.NewPCG(3, 4))
+	bitmap, _ := randomBitmap(random)
+
+	clone := bitmap.Clone()
+	assert.True(t, clone.Equals(bitmap))
+
+	clone.Add(1<<32 - 1)
+	assert.False(t, clone.Equals(bitmap))
+	assert.False(t, bitmap.Contains(1<<32-1))
+
+	// equal sets in different containers
+	runs := New()
+	runs.AddRange(0, 5000)
+	bits := New()
+	for value := range uint32(5000) {
+		bits.Add(value)
+	}
+	assert.True(t, runs.Equals(bits))
+}
+
+const (
+	hookah = iota
+	pets
+	veranda
+	alcohol
+	liveMusic
+	featureCount
+)
+
+func randomFeatures(random *rand.Rand) []int {
+	// features are rare, as they usually are
+	var features []int
+	for feature := range featureCount {
+		if random.IntN(4) == 0 {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+func TestIndexQuery(t *testing.T) {
+	const rows = 2_000_000
+
+	random := rand.New(rand.NewPCG(5, 6))
+	index := NewIndex[int]()
+	masks := make([]uint8, rows)
+	for row := range uint32(rows) {
+		features := randomFeatures(random)
+		index.Add(row, features...)
+		for _, feature := range features {
+			masks[row] |= 1 << feature
+		}
+	}
+	index.RunOptimize()
+
+	tests := map[string]struct {
+		with    []int
+		without []int
+	}{
+		"single":          {with: []int{hookah}},
+		"two":             {with: []int{alcohol, liveMusic}},
+		"two but not one": {with: []int{alcohol, liveMusic}, without: []int{pets}},
+		"only without":    {without: []int{hookah, veranda}},
+		"everything":      {},
+		"unknown":         {with: []int{featureCount}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var required, excluded uint8
+			for _, feature := range test.with {
+				required |= 1 << feature
+			}
+			for _, feature := range test.without {
+				excluded |= 1 << feature
+			}
+
+			expected := []uint32{}
+			for row, mask := range masks {
+				if mask&required == required && mask&excluded == 0 {
+					expected = append(expected, uint32(row))
+				}
+			}
+
+			result := index.Query(test.with, test.without)
+			assert.Equal(t, uint64(len(expected)), result.Cardinality())
+			assert.Equal(t, expected, result.ToSlice())
+		})
+	}
+
+	index.Remove(0, hookah, pets, veranda, alcohol, liveMusic)
+	assert.True(t, index.Query([]int{hookah}, nil).Cardinality() > 0)
+	assert.False(t, index.Query([]int{hookah}, nil).Contains(0))
+	assert.True(t, index.Rows().Contains(0))
+}
+
+func BenchmarkIndexQuery(b *testing.B) {
+	const rows = 5_000_000
+
+	random := rand.New(rand.NewPCG(7, 8))
+	index := NewIndex[int]()
+	masks := make([]uint8, rows)
+	for row := range uint32(rows) {
+		features := randomFeatures(random)
+		index.Add(row, features...)
+		for _, feature := range features {
+			masks[row] |= 1 << feature
+		}
+	}
+	index.RunOptimize()
+
+	b.Run("bitmap", func(b *testing.B) {
+		for range b.N {
+			_ = index.Query([]int{alcohol, liveMusic}, []int{pets})
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		required := uint8(1<<alcohol | 1<<liveMusic)
+		excluded := uint8(1 << pets)
+		for range b.N {
+			var result []uint32
+			for row, mask := range masks {
+				if mask&required == required && mask&excluded == 0 {
+					result = append(result, uint32(row))
+				}
+			}
+			_ = result
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// CountDownLatch releases all waiters once CountDown has been
+// called count times. Unlike CyclicBarrier it can't be reused.
+type CountDownLatch struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	count int
+}
+
+func NewCountDownLatch(count int) *CountDownLatch {
+	if count < 0 {
+		panic("syncx: negative latch count")
+	}
+
+	latch := &CountDownLatch{count: count}
+	latch.cond = sync.NewCond(&latch.mutex)
+	return latch
+}
+
+func (l *CountDownLatch) CountDown() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.count == 0 {
+		return
+	}
+
+	l.count--
+	if l.count == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+func (l *CountDownLatch) Count() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.count
+}
+
+// Wait blocks until the count reaches zero or ctx is done.
+func (l *CountDownLatch) Wait(ctx context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.count == 0 {
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+
+	for l.count > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.cond.Wait()
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package syncx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountDownLatch(t *testing.T) {
+	latch := NewCountDownLatch(3)
+
+	var counter atomic.Int32
+	for i := 0; i < 3; i++ {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			counter.Add(1)
+			latch.CountDown()
+		}()
+	}
+
+	err := latch.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), counter.Load())
+	assert.Equal(t, 0, latch.Count())
+
+	latch.CountDown()
+	assert.Equal(t, 0, latch.Count())
+	assert.NoError(t, latch.Wait(context.Background()))
+}
+
+func TestCountDownLatchWaitWithCancel(t *testing.T) {
+	latch := NewCountDownLatch(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := latch.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, latch.Count())
+}
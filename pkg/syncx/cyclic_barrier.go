@@ -0,0 +1,146 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang_course/pkg/panics"
+)
+
+var ErrBrokenBarrier = errors.New("broken barrier")
+
+type generation struct {
+	broken bool
+}
+
+// CyclicBarrier lets a fixed number of goroutines wait for each other.
+// When the last one arrives the optional action runs and all of them
+// are released, after which the barrier can be reused.
+type CyclicBarrier struct {
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	parties    int
+	waiting    int
+	action     func()
+	generation *generation
+}
+
+func NewCyclicBarrier(parties int, action func()) *CyclicBarrier {
+	if parties <= 0 {
+		panic("syncx: barrier parties must be positive")
+	}
+
+	barrier := &CyclicBarrier{
+		parties:    parties,
+		action:     action,
+		generation: &generation{},
+	}
+
+	barrier.cond = sync.NewCond(&barrier.mutex)
+	return barrier
+}
+
+// Await blocks until all parties have called Await and returns the
+// arrival index: parties-1 for the first goroutine, 0 for the last.
+// If ctx is done before the barrier trips, the barrier is broken and
+// every waiter gets ErrBrokenBarrier.
+func (b *CyclicBarrier) Await(ctx context.Context) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	current := b.generation
+	if current.broken {
+		return 0, ErrBrokenBarrier
+	}
+
+	if err := ctx.Err(); err != nil {
+		b.breakBarrier()
+		return 0, fmt.Errorf("%w: %w", ErrBrokenBarrier, err)
+	}
+
+	b.waiting++
+	index := b.parties - b.waiting
+	if index == 0 {
+		return 0, b.trip()
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.cond.Broadcast()
+	})
+	defer stop()
+
+	for current == b.generation && !current.broken {
+		if err := ctx.Err(); err != nil {
+			b.breakBarrier()
+			return 0, fmt.Errorf("%w: %w", ErrBrokenBarrier, err)
+		}
+		b.cond.Wait()
+	}
+
+	if current.broken {
+		return 0, ErrBrokenBarrier
+	}
+
+	return index, nil
+}
+
+// Reset breaks the barrier for the goroutines currently waiting
+// on it and makes it ready for a new cycle.
+func (b *CyclicBarrier) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.breakBarrier()
+	b.nextGeneration()
+}
+
+func (b *CyclicBarrier) IsBroken() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.generation.broken
+}
+
+func (b *CyclicBarrier) Parties() int {
+	return b.parties
+}
+
+func (b *CyclicBarrier) NumberWaiting() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.waiting
+}
+
+func (b *CyclicBarrier) trip() error {
+	if b.action == nil {
+		b.nextGeneration()
+		return nil
+	}
+
+	err := panics.SafeCall(func() error {
+		b.action()
+		return nil
+	})
+	if err != nil {
+		b.breakBarrier()
+		return fmt.Errorf("%w: action %w", ErrBrokenBarrier, err)
+	}
+
+	b.nextGeneration()
+	return nil
+}
+
+func (b *CyclicBarrier) breakBarrier() {
+	b.generation.broken = true
+	b.waiting = 0
+	b.cond.Broadcast()
+}
+
+func (b *CyclicBarrier) nextGeneration() {
+	b.generation = &generation{}
+	b.waiting = 0
+	b.cond.Broadcast()
+}
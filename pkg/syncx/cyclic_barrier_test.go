@@ -0,0 +1,135 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCyclicBarrier(t *testing.T) {
+	const parties = 5
+
+	var trips atomic.Int32
+	barrier := NewCyclicBarrier(parties, func() {
+		trips.Add(1)
+	})
+
+	for cycle := 1; cycle <= 3; cycle++ {
+		indexes := make(chan int, parties)
+		wg := sync.WaitGroup{}
+		wg.Add(parties)
+
+		for i := 0; i < parties; i++ {
+			go func() {
+				defer wg.Done()
+				index, err := barrier.Await(context.Background())
+				assert.NoError(t, err)
+				indexes <- index
+			}()
+		}
+
+		wg.Wait()
+		close(indexes)
+
+		seen := make(map[int]bool)
+		for index := range indexes {
+			seen[index] = true
+		}
+
+		assert.Len(t, seen, parties)
+		assert.Equal(t, int32(cycle), trips.Load())
+		assert.Equal(t, 0, barrier.NumberWaiting())
+	}
+}
+
+func TestCyclicBarrierActionRunsBeforeRelease(t *testing.T) {
+	var actionDone atomic.Bool
+	barrier := NewCyclicBarrier(2, func() {
+		time.Sleep(50 * time.Millisecond)
+		actionDone.Store(true)
+	})
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := barrier.Await(context.Background())
+			assert.NoError(t, err)
+			assert.True(t, actionDone.Load())
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestCyclicBarrierCancelBreaksForEveryone(t *testing.T) {
+	barrier := NewCyclicBarrier(3, nil)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := barrier.Await(context.Background())
+		errs <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := barrier.Await(ctx)
+	assert.ErrorIs(t, err, ErrBrokenBarrier)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrBrokenBarrier)
+		assert.NotErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released")
+	}
+
+	assert.True(t, barrier.IsBroken())
+	_, err = barrier.Await(context.Background())
+	assert.ErrorIs(t, err, ErrBrokenBarrier)
+}
+
+func TestCyclicBarrierReset(t *testing.T) {
+	barrier := NewCyclicBarrier(2, nil)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := barrier.Await(context.Background())
+		errs <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return barrier.NumberWaiting() == 1
+	}, time.Second, time.Millisecond)
+
+	barrier.Reset()
+	assert.ErrorIs(t, <-errs, ErrBrokenBarrier)
+	assert.False(t, barrier.IsBroken())
+
+	go func() {
+		_, err := barrier.Await(context.Background())
+		errs <- err
+	}()
+
+	_, err := barrier.Await(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, <-errs)
+}
+
+func TestCyclicBarrierPanickingAction(t *testing.T) {
+	barrier := NewCyclicBarrier(1, func() {
+		panic("action")
+	})
+
+	_, err := barrier.Await(context.Background())
+	assert.ErrorIs(t, err, ErrBrokenBarrier)
+	assert.True(t, barrier.IsBroken())
+}
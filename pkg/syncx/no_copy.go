@@ -0,0 +1,9 @@
+package syncx
+
+// NoCopy may be embedded into structs which must not be copied
+// after the first use. It has no size, but both go vet (copylocks)
+// and cmd/lockcopy recognize it as a lock.
+type NoCopy struct{}
+
+func (*NoCopy) Lock()   {}
+func (*NoCopy) Unlock() {}
@@ -0,0 +1,146 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// Phaser is a reusable barrier whose number of parties can change
+// over time. Each phase completes when every registered party has
+// arrived, then the phase number is incremented.
+type Phaser struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	phase   int
+	parties int
+	arrived int
+}
+
+func NewPhaser(parties int) *Phaser {
+	if parties < 0 {
+		panic("syncx: negative phaser parties")
+	}
+
+	phaser := &Phaser{parties: parties}
+	phaser.cond = sync.NewCond(&phaser.mutex)
+	return phaser
+}
+
+// Register adds a new unarrived party and returns the current phase.
+func (p *Phaser) Register() int {
+	return p.BulkRegister(1)
+}
+
+func (p *Phaser) BulkRegister(parties int) int {
+	if parties < 0 {
+		panic("syncx: negative phaser parties")
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.parties += parties
+	return p.phase
+}
+
+// Arrive marks one party as arrived without waiting for the others
+// and returns the phase it arrived at.
+func (p *Phaser) Arrive() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.arrive()
+}
+
+// ArriveAndDeregister arrives and removes the party, so following
+// phases no longer wait for it.
+func (p *Phaser) ArriveAndDeregister() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.parties == 0 {
+		panic("syncx: phaser has no registered parties")
+	}
+
+	phase := p.phase
+	p.parties--
+	p.tryAdvance()
+	return phase
+}
+
+// ArriveAndAwaitAdvance arrives and waits for the other parties,
+// returning the number of the next phase.
+func (p *Phaser) ArriveAndAwaitAdvance(ctx context.Context) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.awaitAdvance(ctx, p.arrive())
+}
+
+// AwaitAdvance waits until the phaser moves past the given phase.
+// It returns immediately if that already happened.
+func (p *Phaser) AwaitAdvance(ctx context.Context, phase int) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.awaitAdvance(ctx, phase)
+}
+
+func (p *Phaser) Phase() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.phase
+}
+
+func (p *Phaser) RegisteredParties() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.parties
+}
+
+func (p *Phaser) ArrivedParties() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.arrived
+}
+
+func (p *Phaser) arrive() int {
+	if p.arrived >= p.parties {
+		panic("syncx: more arrivals than registered parties")
+	}
+
+	phase := p.phase
+	p.arrived++
+	p.tryAdvance()
+	return phase
+}
+
+func (p *Phaser) tryAdvance() {
+	if p.parties > 0 && p.arrived == p.parties {
+		p.phase++
+		p.arrived = 0
+		p.cond.Broadcast()
+	}
+}
+
+func (p *Phaser) awaitAdvance(ctx context.Context, phase int) (int, error) {
+	if p.phase != phase {
+		return p.phase, nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		p.cond.Broadcast()
+	})
+	defer stop()
+
+	for p.phase == phase {
+		if err := ctx.Err(); err != nil {
+			return phase, err
+		}
+		p.cond.Wait()
+	}
+
+	return p.phase, nil
+}
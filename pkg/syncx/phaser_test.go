@@ -0,0 +1,82 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhaser(t *testing.T) {
+	const parties = 4
+
+	phaser := NewPhaser(parties)
+	wg := sync.WaitGroup{}
+	wg.Add(parties)
+
+	for i := 0; i < parties; i++ {
+		go func() {
+			defer wg.Done()
+			for phase := 0; phase < 3; phase++ {
+				next, err := phaser.ArriveAndAwaitAdvance(context.Background())
+				assert.NoError(t, err)
+				assert.Equal(t, phase+1, next)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 3, phaser.Phase())
+}
+
+func TestPhaserDynamicRegistration(t *testing.T) {
+	phaser := NewPhaser(1)
+	assert.Equal(t, 0, phaser.Register())
+	assert.Equal(t, 2, phaser.RegisteredParties())
+
+	assert.Equal(t, 0, phaser.Arrive())
+	assert.Equal(t, 0, phaser.Phase())
+
+	// the last unarrived party leaves, so the phase completes
+	assert.Equal(t, 0, phaser.ArriveAndDeregister())
+	assert.Equal(t, 1, phaser.Phase())
+	assert.Equal(t, 1, phaser.RegisteredParties())
+
+	assert.Equal(t, 1, phaser.Arrive())
+	assert.Equal(t, 2, phaser.Phase())
+}
+
+func TestPhaserDeregisterReleasesWaiters(t *testing.T) {
+	phaser := NewPhaser(2)
+
+	done := make(chan int)
+	go func() {
+		next, err := phaser.ArriveAndAwaitAdvance(context.Background())
+		assert.NoError(t, err)
+		done <- next
+	}()
+
+	assert.Eventually(t, func() bool {
+		return phaser.ArrivedParties() == 1
+	}, time.Second, time.Millisecond)
+
+	phaser.ArriveAndDeregister()
+	assert.Equal(t, 1, <-done)
+}
+
+func TestPhaserAwaitAdvanceWithCancel(t *testing.T) {
+	phaser := NewPhaser(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	phase, err := phaser.ArriveAndAwaitAdvance(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, phase)
+
+	phase, err = phaser.AwaitAdvance(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, phase)
+}
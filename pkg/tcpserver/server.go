@@ -0,0 +1,296 @@
+package tcpserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang_course/pkg/panics"
+)
+
+var ErrServerClosed = errors.New("tcpserver: server closed")
+
+const (
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
+
+type Handler interface {
+	// ServeConn handles a single connection, which is closed after it returns.
+	// ctx is cancelled when the server starts shutting down.
+	ServeConn(ctx context.Context, conn net.Conn)
+}
+
+type HandlerFunc func(ctx context.Context, conn net.Conn)
+
+func (f HandlerFunc) ServeConn(ctx context.Context, conn net.Conn) {
+	f(ctx, conn)
+}
+
+// Server accepts TCP connections and serves each of them in its own
+// goroutine. A panic in the handler closes only its connection.
+type Server struct {
+	Handler Handler
+	// MaxConnections limits the number of connections served at the
+	// same time, further connections wait in the listen backlog.
+	// Zero means no limit.
+	MaxConnections int
+	// ReadTimeout and WriteTimeout are applied before every Read and Write.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// ErrorLog is used for accept errors and panics, log.Default() if nil.
+	ErrorLog *log.Logger
+
+	mutex     sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	active    sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closed    atomic.Bool
+}
+
+func (s *Server) ListenAndServe(address string) error {
+	if s.closed.Load() {
+		return ErrServerClosed
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections until the listener fails or
+// the server is shut down, in which case ErrServerClosed is returned.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.trackListener(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrackListener(listener)
+
+	var slots chan struct{}
+	if s.MaxConnections > 0 {
+		slots = make(chan struct{}, s.MaxConnections)
+	}
+
+	delay := time.Duration(0)
+	for {
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-s.ctx.Done():
+				return ErrServerClosed
+			}
+		}
+
+		conn, err := listener.Accept()
+		if err != nil {
+			if slots != nil {
+				<-slots
+			}
+
+			if s.closed.Load() {
+				return ErrServerClosed
+			}
+			if !isTemporary(err) {
+				return err
+			}
+
+			// give the system time to recover, e.g. to close some files
+			delay = min(max(2*delay, minAcceptDelay), maxAcceptDelay)
+			s.logf("tcpserver: accept error: %v; retrying in %v", err, delay)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-s.ctx.Done():
+				return ErrServerClosed
+			}
+		}
+
+		delay = 0
+		if !s.trackConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go func() {
+			defer func() {
+				if slots != nil {
+					<-slots
+				}
+			}()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Shutdown stops accepting connections, cancels the contexts of the active
+// ones and waits for their handlers to return. If ctx expires first, the
+// remaining connections are closed and ctx.Err() is returned without
+// waiting for handlers which ignore both ctx and the closed connection.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.init()
+	s.closed.Store(true)
+	s.cancel()
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	s.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		s.closeConns()
+		return ctx.Err()
+	}
+}
+
+// Close closes the listeners and all connections immediately.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.untrackConn(conn)
+	defer conn.Close()
+
+	err := panics.SafeCall(func() error {
+		s.Handler.ServeConn(s.ctx, &deadlineConn{
+			Conn:         conn,
+			readTimeout:  s.ReadTimeout,
+			writeTimeout: s.WriteTimeout,
+		})
+		return nil
+	})
+
+	var panicErr *panics.PanicError
+	if errors.As(err, &panicErr) {
+		s.logf("tcpserver: panic serving %v: %v\n%s", conn.RemoteAddr(), panicErr.Value, panicErr.Stack)
+	}
+}
+
+// isTemporary reports whether Accept may succeed after a pause.
+func isTemporary(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) || errors.Is(err, syscall.ECONNABORTED)
+}
+
+func (s *Server) init() {
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[net.Conn]struct{})
+	}
+}
+
+func (s *Server) trackListener(listener net.Listener) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.init()
+	if s.closed.Load() {
+		return false
+	}
+
+	s.listeners[listener] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackListener(listener net.Listener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listener.Close()
+	delete(s.listeners, listener)
+}
+
+func (s *Server) trackConn(conn net.Conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed.Load() {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.active.Add(1)
+	return true
+}
+
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.conns, conn)
+	s.active.Done()
+}
+
+func (s *Server) closeConns() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	logger := s.ErrorLog
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf(format, args...)
+}
+
+// deadlineConn moves the read and write deadlines forward
+// before every operation, so they work as idle timeouts.
+type deadlineConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func (c *deadlineConn) Read(data []byte) (int, error) {
+	if c.readTimeout > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Read(data)
+}
+
+func (c *deadlineConn) Write(data []byte) (int, error) {
+	if c.writeTimeout > 0 {
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Write(data)
+}
@@ -0,0 +1,292 @@
+package tcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logBuffer can be read by tests while the server is writing to it
+type logBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *logBuffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(data)
+}
+
+func (b *logBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func echoHandler(ctx context.Context, conn net.Conn) {
+	_, _ = io.Copy(conn, conn)
+}
+
+func startServer(t *testing.T, server *Server) (string, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	if server.ErrorLog == nil {
+		server.ErrorLog = log.New(io.Discard, "", 0)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	return listener.Addr().String(), served
+}
+
+func roundTrip(t *testing.T, conn net.Conn, message string) string {
+	_, err := conn.Write([]byte(message + "\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	return line[:len(line)-1]
+}
+
+func TestServerEcho(t *testing.T) {
+	server := &Server{Handler: HandlerFunc(echoHandler)}
+	address, served := startServer(t, server)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "hello", roundTrip(t, conn, "hello"))
+
+	require.NoError(t, server.Close())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+}
+
+func TestServerRecoversFromPanic(t *testing.T) {
+	var buffer logBuffer
+	var calls atomic.Int32
+
+	server := &Server{
+		ErrorLog: log.New(&buffer, "", 0),
+		Handler: HandlerFunc(func(ctx context.Context, conn net.Conn) {
+			if calls.Add(1) == 1 {
+				panic(errors.New("internal error"))
+			}
+			echoHandler(ctx, conn)
+		}),
+	}
+	address, _ := startServer(t, server)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	conn.Close()
+
+	conn, err = net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "still alive", roundTrip(t, conn, "still alive"))
+	assert.Contains(t, buffer.String(), "panic serving")
+	assert.Contains(t, buffer.String(), "internal error")
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	server := &Server{Handler: HandlerFunc(echoHandler), MaxConnections: 1}
+	address, _ := startServer(t, server)
+	defer server.Close()
+
+	first, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	assert.Equal(t, "first", roundTrip(t, first, "first"))
+
+	// the connection is established by the kernel, but isn't served yet
+	second, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer second.Close()
+
+	_, err = second.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, second.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err = second.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	first.Close()
+
+	require.NoError(t, second.SetReadDeadline(time.Now().Add(time.Second)))
+	line, err := bufio.NewReader(second).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", line)
+}
+
+func TestServerReadTimeout(t *testing.T) {
+	server := &Server{Handler: HandlerFunc(echoHandler), ReadTimeout: 50 * time.Millisecond}
+	address, _ := startServer(t, server)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "ping", roundTrip(t, conn, "ping"))
+
+	// the server closes an idle connection
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestServerShutdownDrainsConnections(t *testing.T) {
+	var finished atomic.Bool
+	server := &Server{
+		Handler: HandlerFunc(func(ctx context.Context, conn net.Conn) {
+			_, _ = conn.Write([]byte("ready\n"))
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(true)
+		}),
+	}
+	address, served := startServer(t, server)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ready\n", line)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, server.Shutdown(ctx))
+	assert.True(t, finished.Load())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+
+	_, err = net.Dial("tcp", address)
+	assert.Error(t, err)
+	assert.ErrorIs(t, server.ListenAndServe("127.0.0.1:0"), ErrServerClosed)
+}
+
+func TestServerShutdownStuckHandler(t *testing.T) {
+	server := &Server{
+		Handler: HandlerFunc(func(ctx context.Context, conn net.Conn) {
+			// ignores both ctx and the connection
+			_, _ = conn.Write([]byte("ready\n"))
+			time.Sleep(time.Second)
+		}),
+	}
+	address, _ := startServer(t, server)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	server := &Server{
+		Handler: HandlerFunc(func(ctx context.Context, conn net.Conn) {
+			// ignores ctx and waits until the connection is closed
+			_, _ = conn.Write([]byte("ready\n"))
+			_, _ = io.Copy(io.Discard, conn)
+		}),
+	}
+	address, _ := startServer(t, server)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+type flakyListener struct {
+	net.Listener
+	failures atomic.Int32
+	err      error
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures.Add(-1) >= 0 {
+		return nil, l.err
+	}
+	return l.Listener.Accept()
+}
+
+func TestServerAcceptBackoff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	flaky := &flakyListener{Listener: listener, err: &net.OpError{Op: "accept", Net: "tcp", Err: syscall.EMFILE}}
+	flaky.failures.Store(4)
+
+	var buffer logBuffer
+	server := &Server{Handler: HandlerFunc(echoHandler), ErrorLog: log.New(&buffer, "", 0)}
+	go func() {
+		_ = server.Serve(flaky)
+	}()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	start := time.Now()
+	assert.Equal(t, "hello", roundTrip(t, conn, "hello"))
+
+	// 5ms + 10ms + 20ms + 40ms of backoff at most
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, buffer.String(), "retrying in 5ms")
+	assert.Contains(t, buffer.String(), "retrying in 40ms")
+}
+
+func TestServerAcceptPermanentError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	broken := errors.New("listener is broken")
+	flaky := &flakyListener{Listener: listener, err: broken}
+	flaky.failures.Store(1)
+
+	server := &Server{Handler: HandlerFunc(echoHandler), ErrorLog: log.New(io.Discard, "", 0)}
+	defer server.Close()
+
+	assert.ErrorIs(t, server.Serve(flaky), broken)
+}
@@ -0,0 +1,93 @@
+package workstealing
+
+import "sync"
+
+// localQueueSize is the same as the size of a P local run queue in the Go runtime.
+const localQueueSize = 256
+
+// deque is a bounded ring buffer. The owner works with the bottom in
+// LIFO order to keep caches warm, thieves take the oldest tasks from the top.
+type deque struct {
+	mutex sync.Mutex
+	tasks [localQueueSize]Task
+	head  int
+	size  int
+}
+
+func (d *deque) pushBottom(task Task) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.size == localQueueSize {
+		return false
+	}
+
+	d.tasks[(d.head+d.size)%localQueueSize] = task
+	d.size++
+	return true
+}
+
+func (d *deque) popBottom() (Task, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.size == 0 {
+		return nil, false
+	}
+
+	d.size--
+	idx := (d.head + d.size) % localQueueSize
+	task := d.tasks[idx]
+	d.tasks[idx] = nil
+	return task, true
+}
+
+// takeHalf removes the older half of the tasks, it's used both
+// for stealing and for moving tasks to the global queue.
+func (d *deque) takeHalf() []Task {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	count := d.size - d.size/2
+	if count == 0 {
+		return nil
+	}
+
+	tasks := make([]Task, count)
+	for i := range tasks {
+		tasks[i] = d.tasks[d.head]
+		d.tasks[d.head] = nil
+		d.head = (d.head + 1) % localQueueSize
+	}
+
+	d.size -= count
+	return tasks
+}
+
+type globalQueue struct {
+	mutex sync.Mutex
+	tasks []Task
+}
+
+func (q *globalQueue) push(tasks ...Task) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.tasks = append(q.tasks, tasks...)
+}
+
+// popBatch takes up to limit tasks from the front of the queue.
+func (q *globalQueue) popBatch(limit int) []Task {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	count := min(len(q.tasks), limit)
+	if count == 0 {
+		return nil
+	}
+
+	batch := make([]Task, count)
+	copy(batch, q.tasks)
+	clear(q.tasks[:count])
+	q.tasks = q.tasks[count:]
+	return batch
+}
@@ -0,0 +1,239 @@
+package workstealing
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrClosed = errors.New("executor is closed")
+
+// globalCheckInterval makes workers look into the global queue from
+// time to time even if they have local work, the runtime uses 61 too.
+const globalCheckInterval = 61
+
+// Task gets the worker that runs it, so it can spawn subtasks
+// into the local queue of the same worker.
+type Task func(w *Worker)
+
+// Executor runs tasks on a fixed set of workers, each of them has a
+// local deque like a P in the GMP model. Workers without local work
+// take a batch from the global queue or steal half of another
+// worker's deque, and park when there is nothing to do.
+type Executor struct {
+	workers []*Worker
+	global  globalQueue
+	running sync.WaitGroup
+	// pending counts submitted and spawned tasks which aren't done yet,
+	// it only grows from zero in Submit under the mutex
+	pending atomic.Int64
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	drained *sync.Cond
+	closed  bool
+	stopped bool
+	idle    atomic.Int32
+	version atomic.Uint64
+}
+
+type Worker struct {
+	id       int
+	executor *Executor
+	local    deque
+	random   *rand.Rand
+	ticks    uint32
+}
+
+func New(workers int) *Executor {
+	if workers <= 0 {
+		panic("workstealing: workers number must be positive")
+	}
+
+	executor := &Executor{workers: make([]*Worker, workers)}
+	executor.cond = sync.NewCond(&executor.mutex)
+	executor.drained = sync.NewCond(&executor.mutex)
+
+	for id := range executor.workers {
+		executor.workers[id] = &Worker{
+			id:       id,
+			executor: executor,
+			random:   rand.New(rand.NewPCG(rand.Uint64(), uint64(id))),
+		}
+	}
+
+	executor.running.Add(workers)
+	for _, worker := range executor.workers {
+		go func() {
+			defer executor.running.Done()
+			worker.run()
+		}()
+	}
+
+	return executor
+}
+
+// Submit puts a task from outside the executor into the global queue.
+// It's safe to call concurrently with Wait and Shutdown, after
+// Shutdown has started ErrClosed is returned.
+func (e *Executor) Submit(task Task) error {
+	e.mutex.Lock()
+	if e.closed {
+		e.mutex.Unlock()
+		return ErrClosed
+	}
+	e.pending.Add(1)
+	e.mutex.Unlock()
+
+	e.global.push(task)
+	e.wake()
+	return nil
+}
+
+// Wait blocks until all submitted tasks and their subtasks are done,
+// tasks submitted while waiting are waited for too.
+func (e *Executor) Wait() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for e.pending.Load() > 0 {
+		e.drained.Wait()
+	}
+}
+
+// Shutdown rejects new tasks, waits for the accepted ones and stops the workers.
+func (e *Executor) Shutdown() {
+	e.mutex.Lock()
+	e.closed = true
+	for e.pending.Load() > 0 {
+		e.drained.Wait()
+	}
+	e.stopped = true
+	e.cond.Broadcast()
+	e.mutex.Unlock()
+
+	e.running.Wait()
+}
+
+func (e *Executor) done() {
+	if e.pending.Add(-1) == 0 {
+		// the lock makes sure a waiter is either before its check or in Wait
+		e.mutex.Lock()
+		e.drained.Broadcast()
+		e.mutex.Unlock()
+	}
+}
+
+func (e *Executor) wake() {
+	e.version.Add(1)
+	if e.idle.Load() > 0 {
+		e.mutex.Lock()
+		e.cond.Signal()
+		e.mutex.Unlock()
+	}
+}
+
+func (w *Worker) ID() int {
+	return w.id
+}
+
+// Spawn adds a subtask to the local queue of the worker. When the
+// queue is full, half of it goes to the global queue together with
+// the new task. Spawn must only be called from the worker's own task.
+func (w *Worker) Spawn(task Task) {
+	w.executor.pending.Add(1)
+	if !w.local.pushBottom(task) {
+		overflow := append(w.local.takeHalf(), task)
+		w.executor.global.push(overflow...)
+	}
+	w.executor.wake()
+}
+
+func (w *Worker) run() {
+	executor := w.executor
+	for {
+		version := executor.version.Load()
+		if task, ok := w.findTask(); ok {
+			task(w)
+			executor.done()
+			continue
+		}
+
+		executor.mutex.Lock()
+		if executor.stopped {
+			executor.mutex.Unlock()
+			return
+		}
+
+		executor.idle.Add(1)
+		if executor.version.Load() == version {
+			executor.cond.Wait()
+		}
+		executor.idle.Add(-1)
+		executor.mutex.Unlock()
+	}
+}
+
+func (w *Worker) findTask() (Task, bool) {
+	w.ticks++
+	if w.ticks%globalCheckInterval == 0 {
+		if task, ok := w.fromGlobal(); ok {
+			return task, true
+		}
+	}
+
+	if task, ok := w.local.popBottom(); ok {
+		return task, true
+	}
+
+	if task, ok := w.fromGlobal(); ok {
+		return task, true
+	}
+
+	return w.steal()
+}
+
+func (w *Worker) fromGlobal() (Task, bool) {
+	workers := len(w.executor.workers)
+	batch := w.executor.global.popBatch(max(1, min(localQueueSize/2, workers*4)))
+	if len(batch) == 0 {
+		return nil, false
+	}
+
+	for _, task := range batch[1:] {
+		if !w.local.pushBottom(task) {
+			w.executor.global.push(task)
+		}
+	}
+
+	if len(batch) > 1 {
+		w.executor.wake()
+	}
+
+	return batch[0], true
+}
+
+func (w *Worker) steal() (Task, bool) {
+	workers := w.executor.workers
+	offset := w.random.IntN(len(workers))
+	for i := range workers {
+		victim := workers[(offset+i)%len(workers)]
+		if victim == w {
+			continue
+		}
+
+		stolen := victim.local.takeHalf()
+		if len(stolen) == 0 {
+			continue
+		}
+
+		for _, task := range stolen[1:] {
+			w.local.pushBottom(task)
+		}
+
+		return stolen[0], true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,283 @@
+package workstealing
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorRunsSubmittedTasks(t *testing.T) {
+	executor := New(4)
+	defer executor.Shutdown()
+
+	var counter atomic.Int32
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, executor.Submit(func(w *Worker) {
+			counter.Add(1)
+		}))
+	}
+
+	executor.Wait()
+	assert.Equal(t, int32(1000), counter.Load())
+}
+
+func TestExecutorSpawnOverflowsToGlobalQueue(t *testing.T) {
+	executor := New(2)
+	defer executor.Shutdown()
+
+	var counter atomic.Int32
+	_ = executor.Submit(func(w *Worker) {
+		for i := 0; i < 10*localQueueSize; i++ {
+			w.Spawn(func(w *Worker) {
+				counter.Add(1)
+			})
+		}
+	})
+
+	executor.Wait()
+	assert.Equal(t, int32(10*localQueueSize), counter.Load())
+}
+
+func TestExecutorStealsWork(t *testing.T) {
+	executor := New(4)
+	defer executor.Shutdown()
+
+	var mutex sync.Mutex
+	workers := make(map[int]int)
+
+	// all subtasks land in the local queue of a single worker
+	_ = executor.Submit(func(w *Worker) {
+		for i := 0; i < 64; i++ {
+			w.Spawn(func(w *Worker) {
+				time.Sleep(time.Millisecond)
+				mutex.Lock()
+				workers[w.ID()]++
+				mutex.Unlock()
+			})
+		}
+	})
+
+	executor.Wait()
+	assert.Greater(t, len(workers), 1)
+
+	total := 0
+	for _, count := range workers {
+		total += count
+	}
+	assert.Equal(t, 64, total)
+}
+
+func TestExecutorShutdown(t *testing.T) {
+	executor := New(2)
+
+	var counter atomic.Int32
+	_ = executor.Submit(func(w *Worker) {
+		time.Sleep(10 * time.Millisecond)
+		w.Spawn(func(w *Worker) {
+			counter.Add(1)
+		})
+	})
+
+	executor.Shutdown()
+	assert.Equal(t, int32(1), counter.Load())
+	assert.ErrorIs(t, executor.Submit(func(w *Worker) {}), ErrClosed)
+}
+
+func TestExecutorSubmitDuringWaitAndShutdown(t *testing.T) {
+	executor := New(4)
+
+	var accepted, executed atomic.Int32
+	submitters := sync.WaitGroup{}
+	submitters.Add(4)
+	for range 4 {
+		go func() {
+			defer submitters.Done()
+			for range 1000 {
+				err := executor.Submit(func(w *Worker) {
+					executed.Add(1)
+				})
+				if err != nil {
+					assert.ErrorIs(t, err, ErrClosed)
+					return
+				}
+				accepted.Add(1)
+			}
+		}()
+	}
+
+	executor.Wait()
+	executor.Shutdown()
+	submitters.Wait()
+
+	// every accepted task has run before Shutdown returned
+	assert.Equal(t, accepted.Load(), executed.Load())
+}
+
+func TestParallelQuickSort(t *testing.T) {
+	executor := New(runtime.GOMAXPROCS(0))
+	defer executor.Shutdown()
+
+	data := randomSlice(100_000)
+	expected := slices.Clone(data)
+	slices.Sort(expected)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	_ = executor.Submit(func(w *Worker) {
+		quickSort(data, &wg, func(task func()) {
+			w.Spawn(func(*Worker) { task() })
+		})
+	})
+
+	wg.Wait()
+	assert.Equal(t, expected, data)
+}
+
+const sequentialThreshold = 2048
+
+// quickSort sorts data and calls wg.Done when the whole range is sorted,
+// the left half of every partition is forked with spawn.
+func quickSort(data []int, wg *sync.WaitGroup, spawn func(func())) {
+	for len(data) > sequentialThreshold {
+		pivot := partition(data)
+		left := data[:pivot]
+		data = data[pivot+1:]
+
+		wg.Add(1)
+		spawn(func() {
+			quickSort(left, wg, spawn)
+		})
+	}
+
+	slices.Sort(data)
+	wg.Done()
+}
+
+func partition(data []int) int {
+	middle := len(data) / 2
+	data[middle], data[len(data)-1] = data[len(data)-1], data[middle]
+
+	pivot := data[len(data)-1]
+	idx := 0
+	for i := 0; i < len(data)-1; i++ {
+		if data[i] < pivot {
+			data[i], data[idx] = data[idx], data[i]
+			idx++
+		}
+	}
+
+	data[idx], data[len(data)-1] = data[len(data)-1], data[idx]
+	return idx
+}
+
+func randomSlice(size int) []int {
+	random := rand.New(rand.NewPCG(1, 2))
+	data := make([]int, size)
+	for i := range data {
+		data[i] = random.Int()
+	}
+	return data
+}
+
+// channelPool is a baseline with a single shared queue for all workers.
+type channelPool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+}
+
+func newChannelPool(workers int) *channelPool {
+	pool := &channelPool{tasks: make(chan func(), 1024)}
+	pool.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer pool.wg.Done()
+			for task := range pool.tasks {
+				task()
+			}
+		}()
+	}
+	return pool
+}
+
+func (p *channelPool) spawn(task func()) {
+	select {
+	case p.tasks <- task:
+	default:
+		// the queue is full, run inline to avoid deadlock
+		task()
+	}
+}
+
+func (p *channelPool) shutdown() {
+	close(p.tasks)
+	p.wg.Wait()
+}
+
+// go test -bench=QuickSort ./pkg/workstealing
+
+func BenchmarkQuickSortWorkStealing(b *testing.B) {
+	executor := New(runtime.GOMAXPROCS(0))
+	defer executor.Shutdown()
+
+	source := randomSlice(1_000_000)
+	data := make([]int, len(source))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, source)
+		b.StartTimer()
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		_ = executor.Submit(func(w *Worker) {
+			quickSort(data, &wg, func(task func()) {
+				w.Spawn(func(*Worker) { task() })
+			})
+		})
+		wg.Wait()
+	}
+}
+
+func BenchmarkQuickSortSharedChannel(b *testing.B) {
+	pool := newChannelPool(runtime.GOMAXPROCS(0))
+	defer pool.shutdown()
+
+	source := randomSlice(1_000_000)
+	data := make([]int, len(source))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, source)
+		b.StartTimer()
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		pool.spawn(func() {
+			quickSort(data, &wg, pool.spawn)
+		})
+		wg.Wait()
+	}
+}
+
+func BenchmarkQuickSortSequential(b *testing.B) {
+	source := randomSlice(1_000_000)
+	data := make([]int, len(source))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, source)
+		b.StartTimer()
+
+		slices.Sort(data)
+	}
+}